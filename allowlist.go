@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"anime-to-seerr-blocklist/internal/anilist"
+	"anime-to-seerr-blocklist/internal/anime-list"
+	"anime-to-seerr-blocklist/internal/arr"
+	"anime-to-seerr-blocklist/internal/console"
+	"anime-to-seerr-blocklist/internal/jellyfin"
+	"anime-to-seerr-blocklist/internal/mal"
+	"anime-to-seerr-blocklist/internal/plex"
+)
+
+// buildAllowlist collects the TMDB, AniDB and TVDB IDs of series that must never be blocklisted from every configured source
+func buildAllowlist(ctx context.Context, opts *options) {
+	opts.allowlist = make(map[int]struct{})
+	opts.allowlistAnidb = make(map[int]struct{})
+	opts.allowlistTvdb = make(map[int]struct{})
+
+	if opts.allowlistList != "" {
+		if err := addIdList(ctx, opts); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if opts.allowlistPlex {
+		plexToken := os.Getenv("PLEX_TOKEN")
+		if plexToken == "" {
+			log.Fatal("$PLEX_TOKEN is required")
+		}
+
+		plexLibraryClient, err := plexApi.NewClient(plexToken, "")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if err = addPlexWatchlist(ctx, plexLibraryClient, opts.allowlist, opts.verbose); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if opts.allowlistJellyfin {
+		jellyfinHost, jellyfinApiKey := os.Getenv("JELLYFIN_HOST"), os.Getenv("JELLYFIN_API_KEY")
+		if jellyfinHost == "" || jellyfinApiKey == "" {
+			log.Fatal("$JELLYFIN_HOST/$JELLYFIN_API_KEY are required")
+		}
+
+		jellyfinItemsClient, err := jellyfinApi.NewClient(jellyfinHost, jellyfinApiKey, "Items")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if err = addJellyfinLibrary(ctx, jellyfinItemsClient, opts.allowlist, opts.verbose); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if opts.allowlistAnilist {
+		anilistUserName := os.Getenv("ANILIST_USERNAME")
+		if anilistUserName == "" {
+			log.Fatal("$ANILIST_USERNAME is required")
+		}
+
+		anidbIds, err := fetchAnidbIds(ctx, opts.cacheDir, opts.mappingCache, func(e *crossrefEntry) int { return e.AnilistId })
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if err = addAnilistLists(ctx, anilistApi.NewClient(), anilistUserName, anidbIds, opts.allowlistAnidb, opts.verbose); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if opts.allowlistMal {
+		malUserName, malClientId := os.Getenv("MAL_USERNAME"), os.Getenv("MAL_CLIENT_ID")
+		if malUserName == "" || malClientId == "" {
+			log.Fatal("$MAL_USERNAME/$MAL_CLIENT_ID are required")
+		}
+
+		anidbIds, err := fetchAnidbIds(ctx, opts.cacheDir, opts.mappingCache, func(e *crossrefEntry) int { return e.MalId })
+		if err != nil {
+			log.Fatal(err)
+		}
+		malUserClient, err := malApi.NewClient(malClientId, "users")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if err = addMalLists(ctx, malUserClient, malUserName, anidbIds, opts.allowlistAnidb, opts.verbose); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if opts.allowlistSimkl {
+		addSimklLists(ctx, opts)
+	}
+	if opts.allowlistTrakt || opts.blocklistTrakt {
+		addTraktLists(ctx, opts)
+	}
+	if opts.allowlistMdblist || opts.blocklistMdblist {
+		addMdblistLists(ctx, opts)
+	}
+
+	if opts.allowlistShoko {
+		if err := addShokoSeries(ctx, opts.allowlistAnidb, opts.verbose); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	switch opts.allowlistSonarr {
+	case "":
+	case "monitored", "anime":
+		sonarrHost := os.Getenv("SONARR_HOST")
+		sonarrApiKey := os.Getenv("SONARR_API_KEY")
+		if sonarrHost == "" || sonarrApiKey == "" {
+			log.Fatal("$SONARR_HOST/$SONARR_API_KEY are required")
+		}
+
+		sonarrSeriesClient, err := arrApi.NewClient(sonarrHost, sonarrApiKey, "series")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if err = addSonarrSeries(ctx, sonarrSeriesClient, opts.allowlistSonarr == "anime", opts.allowlist, opts.allowlistTvdb, opts.verbose); err != nil {
+			log.Fatal(err)
+		}
+	default:
+		log.Fatalf("unknown -allowlist-sonarr %q", opts.allowlistSonarr)
+	}
+}
+
+// addIdList allowlists the series on -allowlist
+func addIdList(ctx context.Context, opts *options) error {
+	fdp, err := readIdList(ctx, opts, opts.allowlistList)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range fdp {
+		if opts.verbose {
+			console.Skipped("Allowlisting %s (%v) from -allowlist\n", p.Name, p.Tmdbtv)
+		}
+		opts.allowlist[p.Tmdbtv] = struct{}{}
+	}
+	return nil
+}
+
+// readIdList reads a list of TMDB IDs like those -source accepts from a file or URL. A remote list is cached for
+// -allowlist-max-age, so that changes to it are picked up by the next sync after that
+func readIdList(ctx context.Context, opts *options, list string) ([]AnimeList.Anime, error) {
+	var fdp []AnimeList.Anime
+	decode := func(r io.Reader) error {
+		var err error
+		fdp, err = parseIdList(r)
+		return err
+	}
+
+	var err error
+	if isIdListSource(list) {
+		err = fetchCached(ctx, opts.cacheDir, list, cachePolicy{maxAge: opts.allowlistMaxAge, offline: opts.mappingCache.offline}, decode)
+	} else {
+		var file *os.File
+		if file, err = os.Open(list); err == nil {
+			err = decode(file)
+			file.Close()
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", list, err)
+	}
+	return fdp, nil
+}
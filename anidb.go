@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"anime-to-seerr-blocklist/internal/anidb"
+	"anime-to-seerr-blocklist/internal/anime-list"
+	"anime-to-seerr-blocklist/internal/console"
+)
+
+const anidbCacheFile = "anidb-anime.json"
+
+// AniDB bans clients that fetch the same anime more than once a day or that don't wait at least two seconds between
+// requests, so details are kept for long and requested with a margin
+const (
+	anidbCacheMaxAge       = 30 * 24 * time.Hour
+	anidbRequestInterval   = 4 * time.Second
+	defaultAnidbMaxLookups = 200
+)
+
+type anidbDetails struct {
+	Type       string    `json:"type"`
+	Episodes   int       `json:"episodes"`
+	Restricted bool      `json:"restricted"`
+	Fetched    time.Time `json:"fetched"`
+}
+
+// anidbCache keeps the details of anime looked up on AniDB across runs. As the lookups are slow, at most maxLookups
+// are made per run and the rest are left for later runs
+type anidbCache struct {
+	filename    string
+	entries     map[int]*anidbDetails
+	anidbClient *anidbApi.Client
+	maxLookups  int
+	lookups     int
+	lastLookup  time.Time
+	// banned stops any more lookups once AniDB has refused them
+	banned   bool
+	modified bool
+}
+
+func openAnidbCache(cacheDir string, maxLookups int) (*anidbCache, error) {
+	clientName, clientVersion := os.Getenv("ANIDB_CLIENT"), os.Getenv("ANIDB_CLIENT_VERSION")
+	if clientName == "" || clientVersion == "" {
+		return nil, errors.New("$ANIDB_CLIENT/$ANIDB_CLIENT_VERSION of a client registered on AniDB are required")
+	}
+	anidbClient, err := anidbApi.NewClient(clientName, clientVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &anidbCache{
+		filename:    filepath.Join(cacheDir, anidbCacheFile),
+		entries:     make(map[int]*anidbDetails),
+		anidbClient: anidbClient,
+		maxLookups:  maxLookups,
+	}
+
+	b, err := os.ReadFile(c.filename)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return c, nil
+		}
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &c.entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", c.filename, err)
+	}
+
+	return c, nil
+}
+
+// get returns the details of the AniDB entry anidbId, or nil if they're not cached and can't be looked up this run
+func (c *anidbCache) get(ctx context.Context, anidbId int) (*anidbDetails, error) {
+	cached, ok := c.entries[anidbId]
+	if ok && time.Since(cached.Fetched) < anidbCacheMaxAge {
+		return cached, nil
+	}
+	if c.banned || (c.maxLookups > 0 && c.lookups >= c.maxLookups) {
+		// Better out of date than nothing
+		return cached, nil
+	}
+
+	if wait := time.Until(c.lastLookup.Add(anidbRequestInterval)); wait > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, context.Cause(ctx)
+		case <-time.After(wait):
+		}
+	}
+	c.lookups++
+	c.lastLookup = time.Now()
+
+	anime, err := c.anidbClient.GetAnime(ctx, anidbId)
+	if err != nil {
+		if apiErr, ok := errors.AsType[*anidbApi.Error](err); ok && apiErr.Banned() {
+			c.banned = true
+		}
+		return cached, err
+	}
+
+	details := &anidbDetails{
+		Type:       anime.Type,
+		Episodes:   anime.EpisodeCount,
+		Restricted: anime.Restricted,
+		Fetched:    time.Now(),
+	}
+	c.entries[anidbId] = details
+	c.modified = true
+	return details, nil
+}
+
+func (c *anidbCache) save() error {
+	if !c.modified {
+		return nil
+	}
+
+	b, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.filename, b, 0o644)
+}
+
+// anidbFiltered reports whether opts filter any anime by their details on AniDB
+func anidbFiltered(opts *options) bool {
+	return opts.anidbTypes != "" || opts.minEpisodes > 0 || opts.skipRestricted
+}
+
+// dropByAnidbDetails drops the anime from the mapping that opts filter out by their details on AniDB. Anime whose
+// details aren't known yet are kept
+func dropByAnidbDetails(ctx context.Context, opts *options, fdp []AnimeList.Anime, anidbCache *anidbCache) []AnimeList.Anime {
+	var types []string
+	if opts.anidbTypes != "" {
+		types = strings.Split(opts.anidbTypes, ",")
+	}
+
+	unknown := 0
+	fdp = slices.DeleteFunc(fdp, func(p AnimeList.Anime) bool {
+		if p.Anidbid == 0 || p.Tmdbtv == 0 || ctx.Err() != nil {
+			return false
+		}
+
+		details, err := anidbCache.get(ctx, p.Anidbid)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Error looking up %s (AniDB %v): %v", p.Name, p.Anidbid, err)
+		}
+		if details == nil {
+			unknown++
+			return false
+		}
+
+		var reason string
+		switch {
+		case types != nil && !slices.ContainsFunc(types, func(t string) bool { return strings.EqualFold(strings.TrimSpace(t), details.Type) }):
+			reason = fmt.Sprintf("it's a %s", details.Type)
+		case opts.minEpisodes > 0 && details.Episodes > 0 && details.Episodes < opts.minEpisodes:
+			reason = fmt.Sprintf("it has %d episodes", details.Episodes)
+		case opts.skipRestricted && details.Restricted:
+			reason = "it's restricted"
+		default:
+			return false
+		}
+		if opts.verbose {
+			console.Skipped("Skipping %s (AniDB %v) as %s\n", p.Name, p.Anidbid, reason)
+		}
+		return true
+	})
+
+	if unknown > 0 {
+		log.Printf("%d anime not looked up on AniDB yet were kept, later runs will look them up", unknown)
+	}
+	return fdp
+}
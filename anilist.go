@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+
+	"anime-to-seerr-blocklist/internal/anilist"
+	"anime-to-seerr-blocklist/internal/console"
+)
+
+// addAnilistLists allowlists the anime an AniList user is watching or planning to watch
+func addAnilistLists(ctx context.Context, anilistClient *anilistApi.Client, userName string, anidbIds map[int]int, allowlistAnidb map[int]struct{}, verbose bool) error {
+	var data anilistApi.MediaListCollectionData
+
+	err := anilistClient.Query(ctx, anilistApi.MediaListCollectionQuery, map[string]any{
+		"userName": userName,
+		"status":   []string{"PLANNING", "CURRENT"},
+	}, &data)
+	if err != nil {
+		return err
+	}
+
+	for _, list := range data.MediaListCollection.Lists {
+		for _, entry := range list.Entries {
+			if anidbId, ok := anidbIds[entry.Media.Id]; ok {
+				if verbose {
+					console.Skipped("Allowlisting %s (AniDB %v) from AniList\n", entry.Media.Title.Romaji, anidbId)
+				}
+				allowlistAnidb[anidbId] = struct{}{}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"anime-to-seerr-blocklist/internal/anime-list"
+	"anime-to-seerr-blocklist/internal/atomicfile"
+	"anime-to-seerr-blocklist/internal/console"
+	"anime-to-seerr-blocklist/internal/seerr"
+	"anime-to-seerr-blocklist/pkg/blocklistsync"
+)
+
+const (
+	reverse      = "\x1b[7m"
+	resetStyle   = "\x1b[0m"
+	browseChrome = 4
+)
+
+type browseEntry struct {
+	p AnimeList.Anime
+	// ignored is whether -mapping-overrides drops the entry
+	ignored bool
+}
+
+// browseModel lists the mapping's entries with what a sync would do with them, and records the decisions made on them
+// in -allowlist and -mapping-overrides
+type browseModel struct {
+	entries []browseEntry
+	// shown holds the indices of the entries matching query
+	shown          []int
+	cursor, offset int
+	width, height  int
+	searching      bool
+	query          string
+
+	allowlist     map[int]struct{}
+	allowlistFile string
+	overridesFile string
+	// blocklisted is the Seerr blocklist, nil if it wasn't fetched
+	blocklisted map[int]struct{}
+	message     string
+}
+
+func runBrowse(ctx context.Context, opts *options, args []string) {
+	fs := flag.NewFlagSet("browse", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: anime-to-seerr-blocklist [-allowlist file] [-mapping-overrides file] browse")
+		fmt.Fprintln(fs.Output(), "Browses the mapping, allowlisting series in -allowlist and ignoring entries in -mapping-overrides")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	if !console.IsTerminal(os.Stdin) || !console.IsTerminal(os.Stdout) {
+		log.Fatal("browse needs a terminal")
+	}
+
+	m := &browseModel{
+		allowlist:     make(map[int]struct{}),
+		overridesFile: opts.mappingOverrides,
+	}
+	if opts.allowlistList != "" && !isIdListSource(opts.allowlistList) {
+		m.allowlistFile = opts.allowlistList
+	}
+	if opts.allowlistList != "" {
+		fdp, err := readIdList(ctx, opts, opts.allowlistList)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Fatal(err)
+		}
+		for _, p := range fdp {
+			m.allowlist[p.Tmdbtv] = struct{}{}
+		}
+	}
+
+	overrides, err := loadMappingOverrides(opts.mappingOverrides, false)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Fatal(err)
+	}
+	if overrides == nil {
+		overrides, _ = loadMappingOverrides("", false)
+	}
+	fdp, err := readMappingFile(ctx, opts, func(*AnimeList.Anime) bool { return true })
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, p := range fdp {
+		ignored := !overrides.apply(&p)
+		m.entries = append(m.entries, browseEntry{p: p, ignored: ignored})
+	}
+	for _, p := range overrides.additions() {
+		m.entries = append(m.entries, browseEntry{p: p})
+	}
+
+	if seerrHost, seerrApiKey := os.Getenv("SEERR_HOST"), os.Getenv("SEERR_API_KEY"); seerrHost != "" && seerrApiKey != "" {
+		seerr, err := seerrApi.NewClient(seerrHost, seerrApiKey)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if m.blocklisted, _, err = blocklistSync.Blocklisted(ctx, seerr.Blocklist()); err != nil {
+			log.Fatalf("Error fetching blocklist: %v", err)
+		}
+	}
+
+	m.filter()
+	if _, err = tea.NewProgram(m, tea.WithAltScreen(), tea.WithContext(ctx)).Run(); err != nil && !errors.Is(err, tea.ErrProgramKilled) {
+		log.Fatal(err)
+	}
+}
+
+func (m *browseModel) Init() tea.Cmd {
+	return nil
+}
+
+// filter shows the entries whose name contains query, or whose AniDB or TMDB ID is query
+func (m *browseModel) filter() {
+	query := strings.ToLower(strings.TrimSpace(m.query))
+	m.shown = m.shown[:0]
+	for i, e := range m.entries {
+		if query == "" || strings.Contains(strings.ToLower(e.p.Name), query) || strconv.Itoa(e.p.Anidbid) == query || strconv.Itoa(e.p.Tmdbtv) == query {
+			m.shown = append(m.shown, i)
+		}
+	}
+	m.cursor, m.offset = 0, 0
+}
+
+func (m *browseModel) rows() int {
+	return max(m.height-browseChrome, 1)
+}
+
+func (m *browseModel) move(delta int) {
+	m.cursor = min(max(m.cursor+delta, 0), max(len(m.shown)-1, 0))
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	} else if m.cursor >= m.offset+m.rows() {
+		m.offset = m.cursor - m.rows() + 1
+	}
+}
+
+func (m *browseModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.move(0)
+	case tea.KeyMsg:
+		if m.searching {
+			switch msg.Type {
+			case tea.KeyEnter:
+				m.searching = false
+			case tea.KeyEsc:
+				m.searching, m.query = false, ""
+				m.filter()
+			case tea.KeyBackspace:
+				if r := []rune(m.query); len(r) > 0 {
+					m.query = string(r[:len(r)-1])
+					m.filter()
+				}
+			case tea.KeyRunes, tea.KeySpace:
+				m.query += string(msg.Runes)
+				m.filter()
+			case tea.KeyCtrlC:
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+
+		m.message = ""
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return m, tea.Quit
+		case "up", "k":
+			m.move(-1)
+		case "down", "j":
+			m.move(1)
+		case "pgup":
+			m.move(-m.rows())
+		case "pgdown":
+			m.move(m.rows())
+		case "home", "g":
+			m.move(-len(m.shown))
+		case "end", "G":
+			m.move(len(m.shown))
+		case "/":
+			m.searching = true
+		case "esc":
+			m.query = ""
+			m.filter()
+		case " ", "a":
+			m.toggleAllowlisted()
+		case "i":
+			m.toggleIgnored()
+		}
+	}
+	return m, nil
+}
+
+func (m *browseModel) selected() *browseEntry {
+	if len(m.shown) == 0 {
+		return nil
+	}
+	return &m.entries[m.shown[m.cursor]]
+}
+
+// toggleAllowlisted adds the selected entry's series to -allowlist, or removes it if it's already there
+func (m *browseModel) toggleAllowlisted() {
+	e := m.selected()
+	switch {
+	case e == nil:
+		return
+	case m.allowlistFile == "":
+		m.message = "Allowlisting needs -allowlist to be a local file"
+		return
+	case e.p.Tmdbtv == 0:
+		m.message = "Only series mapped to TMDB can be allowlisted"
+		return
+	}
+
+	_, allowlisted := m.allowlist[e.p.Tmdbtv]
+	if err := writeAllowlisted(m.allowlistFile, e.p.Tmdbtv, e.p.Name, !allowlisted); err != nil {
+		m.message = fmt.Sprintf("Error writing %s: %v", m.allowlistFile, err)
+		return
+	}
+	if allowlisted {
+		delete(m.allowlist, e.p.Tmdbtv)
+		m.message = fmt.Sprintf("Removed %s (%v) from the allowlist", e.p.Name, e.p.Tmdbtv)
+	} else {
+		m.allowlist[e.p.Tmdbtv] = struct{}{}
+		m.message = fmt.Sprintf("Allowlisted %s (%v)", e.p.Name, e.p.Tmdbtv)
+	}
+}
+
+// toggleIgnored makes -mapping-overrides drop the selected AniDB entry from the mapping, or keep it again
+func (m *browseModel) toggleIgnored() {
+	e := m.selected()
+	switch {
+	case e == nil:
+		return
+	case m.overridesFile == "":
+		m.message = "Ignoring entries needs -mapping-overrides"
+		return
+	case e.p.Anidbid == 0:
+		return
+	}
+
+	if err := writeOverrideIgnored(m.overridesFile, e.p.Anidbid, e.p.Name, !e.ignored); err != nil {
+		m.message = fmt.Sprintf("Error writing %s: %v", m.overridesFile, err)
+		return
+	}
+	e.ignored = !e.ignored
+	if e.ignored {
+		m.message = fmt.Sprintf("Ignoring %s (AniDB %v)", e.p.Name, e.p.Anidbid)
+	} else {
+		m.message = fmt.Sprintf("No longer ignoring %s (AniDB %v)", e.p.Name, e.p.Anidbid)
+	}
+}
+
+// status describes what a sync does with e
+func (m *browseModel) status(e *browseEntry) string {
+	_, allowlisted := m.allowlist[e.p.Tmdbtv]
+	switch {
+	case e.ignored:
+		return "ignored"
+	case e.p.Tmdbtv == 0 && len(movieTmdbIds(&e.p)) > 0:
+		return "movie"
+	case e.p.Tmdbtv == 0:
+		return "unmapped"
+	case allowlisted:
+		return "allowlisted"
+	}
+	return "blocked"
+}
+
+func (m *browseModel) View() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%-8s %-8s %-12s %-10s %s\n", "ANIDB", "TMDB", "STATUS", "SEERR", "TITLE")
+	for row := m.offset; row < len(m.shown) && row < m.offset+m.rows(); row++ {
+		e := &m.entries[m.shown[row]]
+
+		tmdb := "-"
+		if e.p.Tmdbtv != 0 {
+			tmdb = strconv.Itoa(e.p.Tmdbtv)
+		}
+		seerr := "?"
+		if m.blocklisted != nil {
+			seerr = "-"
+			if _, ok := m.blocklisted[e.p.Tmdbtv]; ok && e.p.Tmdbtv != 0 {
+				seerr = "blocklisted"
+			}
+		}
+		line := fmt.Sprintf("%-8d %-8s %-12s %-10s %s", e.p.Anidbid, tmdb, m.status(e), seerr, e.p.Name)
+		if r := []rune(line); m.width > 0 && len(r) > m.width {
+			line = string(r[:m.width])
+		}
+		if row == m.cursor {
+			line = reverse + line + resetStyle
+		}
+		b.WriteString(line + "\n")
+	}
+	for row := len(m.shown) - m.offset; row < m.rows(); row++ {
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "%d of %d entries", len(m.shown), len(m.entries))
+	if m.query != "" || m.searching {
+		fmt.Fprintf(&b, " matching %q", m.query)
+	}
+	b.WriteString("\n")
+	switch {
+	case m.searching:
+		b.WriteString("Search: " + m.query + "█")
+	case m.message != "":
+		b.WriteString(m.message)
+	default:
+		b.WriteString("↑/↓ move  / search  space allowlist  i ignore  q quit")
+	}
+	return b.String()
+}
+
+// writeAllowlisted adds tmdbId to the list of TMDB IDs in filename, or removes it, leaving the rest of the file as it
+// was
+func writeAllowlisted(filename string, tmdbId int, title string, allowlisted bool) error {
+	b, err := os.ReadFile(filename)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	var lines []string
+	for line := range strings.Lines(string(b)) {
+		if fields := strings.Fields(line); len(fields) > 0 && fields[0] == strconv.Itoa(tmdbId) {
+			continue
+		}
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+	if allowlisted {
+		lines = append(lines, strings.TrimSpace(fmt.Sprintf("%d %s", tmdbId, title)))
+	}
+
+	var out string
+	if len(lines) > 0 {
+		out = strings.Join(lines, "\n") + "\n"
+	}
+	return atomicFile.WriteFile(filename, []byte(out))
+}
+
+// writeOverrideIgnored sets whether the override for anidbId in filename ignores it, adding the override if there isn't
+// one and removing it once it does nothing else
+func writeOverrideIgnored(filename string, anidbId int, name string, ignored bool) error {
+	var overrides []mappingOverride
+	b, err := os.ReadFile(filename)
+	if err == nil {
+		if err = json.Unmarshal(b, &overrides); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	i := slices.IndexFunc(overrides, func(o mappingOverride) bool { return o.Anidbid == anidbId })
+	switch {
+	case i < 0 && ignored:
+		overrides = append(overrides, mappingOverride{Anidbid: anidbId, Name: name, Ignore: true})
+	case i < 0:
+		return nil
+	case !ignored && overrides[i].Tmdbtv <= 0:
+		overrides = slices.Delete(overrides, i, i+1)
+	default:
+		overrides[i].Ignore = ignored
+	}
+
+	if b, err = json.MarshalIndent(overrides, "", "  "); err != nil {
+		return err
+	}
+	return atomicFile.WriteFile(filename, append(b, '\n'))
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"codeberg.org/sdassow/atomic"
+
+	"anime-to-seerr-blocklist/internal/atomicfile"
+	"anime-to-seerr-blocklist/internal/rest"
+)
+
+// maxDownloadSize bounds the size of a mapping, which is an order of magnitude smaller, in case of a corrupt upstream
+// file or a misbehaving proxy
+const maxDownloadSize = 256 << 20
+
+// Unlike http.DefaultClient, doesn't wait forever on a stalled download
+var downloadClient = &http.Client{Timeout: 10 * time.Minute}
+
+// cachePolicy controls when fetchCached downloads a file again
+type cachePolicy struct {
+	maxAge time.Duration
+	// offline uses the cached copy whatever its age and never downloads
+	offline bool
+	// force always downloads, even if the cached copy is up to date
+	force bool
+}
+
+// cachedFilename returns where fetchCached keeps the gzip-compressed copy of rawUrl, named after a hash of the whole
+// URL so that URLs sharing a basename don't overwrite each other's copies, and query strings don't end up in filenames
+func cachedFilename(cacheDir, rawUrl string) string {
+	sum := sha256.Sum256([]byte(rawUrl))
+	return filepath.Join(cacheDir, hex.EncodeToString(sum[:])+".gz")
+}
+
+// legacyCachedFilenames returns where older versions kept rawUrl and its checksum and commit, named after its basename
+func legacyCachedFilenames(cacheDir, rawUrl string) []string {
+	base := filepath.Join(cacheDir, filepath.Base(rawUrl))
+	return []string{base, base + ".gz", base + ".gz.sha256", base + ".gz.commit"}
+}
+
+// urlBase returns the last element of rawUrl's path, to name it in messages
+func urlBase(rawUrl string) string {
+	if u, err := url.Parse(rawUrl); err == nil {
+		return path.Base(u.Path)
+	}
+	return filepath.Base(rawUrl)
+}
+
+// isUrl reports whether source is an http(s) URL to fetch rather than a local file or the name of a source
+func isUrl(source string) bool {
+	return strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://")
+}
+
+// fetchCached passes the contents of rawUrl to decode, reading them from a copy in cacheDir if that was downloaded
+// within policy's maxAge. Otherwise, the cached copy is replaced once the download has been decoded successfully. A
+// cached copy that's corrupt or can't be decoded is downloaded again, so decode must not rely on state left behind by
+// an earlier call
+func fetchCached(ctx context.Context, cacheDir, rawUrl string, policy cachePolicy, decode func(r io.Reader) error) error {
+	filename := cachedFilename(cacheDir, rawUrl)
+
+	fi, statErr := os.Stat(filename)
+	if policy.offline && statErr != nil {
+		if errors.Is(statErr, fs.ErrNotExist) {
+			return fmt.Errorf("no cached copy of %s in %s, run once without -offline to download it", urlBase(rawUrl), cacheDir)
+		}
+		return statErr
+	}
+
+	if statErr == nil && !policy.force && (policy.offline || time.Since(fi.ModTime()) < policy.maxAge) {
+		err := readCached(filename, decode)
+		if err == nil || policy.offline {
+			return err
+		}
+		log.Printf("Error reading cached %s, downloading it again: %v", urlBase(rawUrl), err)
+		policy.force = true
+	}
+
+	// The cached copy is out of date, but it's only worth downloading again if the file has since changed upstream
+	commit, err := latestGithubCommit(ctx, rawUrl)
+	if err == nil && statErr == nil && !policy.force {
+		if cachedCommit, err := os.ReadFile(filename + ".commit"); err == nil && strings.TrimSpace(string(cachedCommit)) == commit {
+			if err = readCached(filename, decode); err == nil {
+				now := time.Now()
+				_ = os.Chtimes(filename, now, now)
+				return nil
+			}
+		}
+	}
+
+	if statErr != nil {
+		fi = nil
+	}
+	if err = downloadRetrying(ctx, rawUrl, filename, fi, decode); err != nil {
+		if fi == nil || ctx.Err() != nil {
+			return err
+		}
+		// Better out of date than nothing
+		if staleErr := readCached(filename, decode); staleErr != nil {
+			return err
+		}
+		log.Printf("Error downloading %s, using the cached copy from %s: %v", urlBase(rawUrl), fi.ModTime().Format(time.DateTime), err)
+		return nil
+	}
+	if commit == "" {
+		// Not known, so don't leave an outdated one behind
+		_ = os.Remove(filename + ".commit")
+		return nil
+	}
+	return atomicFile.WriteFile(filename+".commit", []byte(commit+"\n"))
+}
+
+// readCached verifies filename against the checksum recorded when it was downloaded and passes its contents to decode
+func readCached(filename string, decode func(r io.Reader) error) error {
+	want, err := os.ReadFile(filename + ".sha256")
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err = io.Copy(h, file); err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != strings.TrimSpace(string(want)) {
+		return fmt.Errorf("checksum mismatch for %q", filename)
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("cannot read %q: %v", filename, err)
+	}
+	return decode(zr)
+}
+
+// downloadRetrying is downloadCached retrying transient failures with exponential backoff
+func downloadRetrying(ctx context.Context, rawUrl, filename string, fi os.FileInfo, decode func(r io.Reader) error) error {
+	const attempts = 4
+	delay := 2 * time.Second
+
+	for attempt := 1; ; attempt++ {
+		err := downloadCached(ctx, rawUrl, filename, fi, decode)
+		if err == nil || attempt == attempts || !isTransientDownloadError(err) || ctx.Err() != nil {
+			return err
+		}
+
+		log.Printf("Error downloading %s, retrying in %v: %v", urlBase(rawUrl), delay, err)
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// isTransientDownloadError reports whether a download that failed with err might succeed if retried, unlike one that
+// couldn't be decoded
+func isTransientDownloadError(err error) bool {
+	if httpErr, ok := errors.AsType[*restApi.HTTPError](err); ok {
+		return httpErr.StatusCode >= http.StatusInternalServerError || httpErr.StatusCode == http.StatusTooManyRequests
+	}
+	if _, ok := errors.AsType[net.Error](err); ok {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// downloadCached passes rawUrl's contents to decode while compressing them into filename, which is only replaced once
+// decode succeeds. fi is the existing file's, if any, to preserve its mode
+func downloadCached(ctx context.Context, rawUrl, filename string, fi os.FileInfo, decode func(r io.Reader) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawUrl, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", restApi.UserAgent)
+
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body := restApi.LimitReader(resp.Body, maxDownloadSize)
+
+	if resp.StatusCode != http.StatusOK {
+		return &restApi.HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Method: http.MethodGet, URL: rawUrl}
+	}
+
+	// https://github.com/natefinch/atomic/blob/master/atomic.go
+	dir, file := filepath.Split(filename)
+	if dir == "" {
+		dir = "."
+	}
+
+	f, err := os.CreateTemp(dir, file)
+	if err != nil {
+		return fmt.Errorf("cannot create temp file: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(f.Name())
+		}
+	}()
+	defer f.Close()
+	fname := f.Name()
+
+	h := sha256.New()
+	zw := gzip.NewWriter(io.MultiWriter(f, h))
+	r := io.TeeReader(body, zw)
+	err = decode(r)
+	if err != nil {
+		return err
+	}
+	// Store the whole download even if decode didn't need to read all of it
+	if _, err = io.Copy(zw, body); err != nil {
+		return err
+	}
+	if err = zw.Close(); err != nil {
+		return fmt.Errorf("cannot compress tempfile %q: %v", fname, err)
+	}
+
+	err = f.Sync()
+	if err != nil {
+		return fmt.Errorf("cannot flush tempfile %q: %v", fname, err)
+	}
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf("cannot close tempfile %q: %v", fname, err)
+	}
+
+	if fi != nil {
+		if fileMode := fi.Mode(); fileMode != 0 {
+			err = os.Chmod(fname, fileMode)
+			if err != nil {
+				return fmt.Errorf("cannot set filemode on tempfile %q: %v", fname, err)
+			}
+		}
+	}
+	err = atomic.ReplaceFile(fname, filename)
+	if err != nil {
+		return fmt.Errorf("cannot replace %q with tempfile %q: %v", filename, fname, err)
+	}
+	// Copies cached by older versions
+	for _, legacy := range legacyCachedFilenames(filepath.Dir(filename), rawUrl) {
+		_ = os.Remove(legacy)
+	}
+
+	return atomicFile.WriteFile(filename+".sha256", []byte(hex.EncodeToString(h.Sum(nil))+"\n"))
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"anime-to-seerr-blocklist/internal/anime-list"
+)
+
+func TestMappingFileSameBasename(t *testing.T) {
+	mux := http.NewServeMux()
+	for _, anidbId := range []int{1, 2} {
+		mux.HandleFunc(fmt.Sprintf("GET /%d/anime-list.xml", anidbId), func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprintf(w, `<anime-list><anime anidbid="%d" tvdbid="100" tmdbtv="1001"/></anime-list>`, anidbId)
+		})
+	}
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	urls := []string{server.URL + "/1/anime-list.xml", server.URL + "/2/anime-list.xml?token=secret"}
+	if cachedFilename(cacheDir, urls[0]) == cachedFilename(cacheDir, urls[1]) {
+		t.Fatalf("%s and %s share cache file %s", urls[0], urls[1], cachedFilename(cacheDir, urls[0]))
+	}
+
+	keepAll := func(*AnimeList.Anime) bool { return true }
+	// Downloaded, then read back from the cache with the server gone
+	for _, policy := range []cachePolicy{{maxAge: time.Hour}, {offline: true}} {
+		if policy.offline {
+			server.Close()
+		}
+		for i, rawUrl := range urls {
+			fdp, err := readMappingFile(t.Context(), &options{cacheDir: cacheDir, mappingFile: rawUrl, mappingCache: policy}, keepAll)
+			if err != nil {
+				t.Fatalf("%s (offline %t): %v", rawUrl, policy.offline, err)
+			}
+			if len(fdp) != 1 || fdp[0].Anidbid != i+1 {
+				t.Errorf("%s (offline %t) = %+v, want anidbid %d", rawUrl, policy.offline, fdp, i+1)
+			}
+		}
+	}
+}
+
+func TestFetchCached(t *testing.T) {
+	const maxAge = time.Hour
+	tests := []struct {
+		name string
+		// cached is the copy downloaded beforehand, if any, age old
+		cached string
+		age    time.Duration
+		// corrupt overwrites the cached copy without updating its checksum
+		corrupt bool
+		// served is what the server returns, or nothing with a 404 if empty, and decodeErr fails decoding only that
+		served       string
+		decodeErr    bool
+		offline      bool
+		want         string
+		wantErr      bool
+		wantRequests int32
+	}{
+		{name: "fresh", cached: "old", served: "new", want: "old"},
+		{name: "stale", cached: "old", age: 2 * maxAge, served: "new", want: "new", wantRequests: 1},
+		{name: "missing", served: "new", want: "new", wantRequests: 1},
+		{name: "missing and unavailable", wantErr: true, wantRequests: 1},
+		{name: "stale and unavailable", cached: "old", age: 2 * maxAge, want: "old", wantRequests: 1},
+		{name: "stale and undecodable download", cached: "old", age: 2 * maxAge, served: "new", decodeErr: true, want: "old", wantRequests: 1},
+		{name: "corrupt", cached: "old", corrupt: true, served: "new", want: "new", wantRequests: 1},
+		{name: "corrupt and unavailable", cached: "old", corrupt: true, wantErr: true, wantRequests: 1},
+		{name: "offline stale", cached: "old", age: 2 * maxAge, served: "new", offline: true, want: "old"},
+		{name: "offline corrupt", cached: "old", corrupt: true, served: "new", offline: true, wantErr: true},
+		{name: "offline missing", served: "new", offline: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			served := &tt.cached
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				requests.Add(1)
+				if *served == "" {
+					http.NotFound(w, nil)
+					return
+				}
+				_, _ = io.WriteString(w, *served)
+			}))
+			defer server.Close()
+
+			cacheDir := t.TempDir()
+			rawUrl := server.URL + "/list.txt"
+			read := func(policy cachePolicy, undecodable string) (string, error) {
+				var got string
+				err := fetchCached(t.Context(), cacheDir, rawUrl, policy, func(r io.Reader) error {
+					b, err := io.ReadAll(r)
+					if err != nil {
+						return err
+					}
+					if string(b) == undecodable {
+						return errors.New("can't decode")
+					}
+					got = string(b)
+					return nil
+				})
+				return got, err
+			}
+
+			filename := cachedFilename(cacheDir, rawUrl)
+			if tt.cached != "" {
+				if _, err := read(cachePolicy{maxAge: maxAge}, ""); err != nil {
+					t.Fatal(err)
+				}
+				if tt.corrupt {
+					if err := os.WriteFile(filename, []byte("corrupt"), 0o644); err != nil {
+						t.Fatal(err)
+					}
+				}
+				modTime := time.Now().Add(-tt.age)
+				if err := os.Chtimes(filename, modTime, modTime); err != nil {
+					t.Fatal(err)
+				}
+			}
+			served = &tt.served
+			requests.Store(0)
+
+			var undecodable string
+			if tt.decodeErr {
+				undecodable = tt.served
+			}
+			got, err := read(cachePolicy{maxAge: maxAge, offline: tt.offline}, undecodable)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("fetchCached() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("fetchCached() = %q, want %q", got, tt.want)
+			}
+			if n := requests.Load(); n != tt.wantRequests {
+				t.Errorf("%d requests, want %d", n, tt.wantRequests)
+			}
+		})
+	}
+}
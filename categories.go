@@ -0,0 +1,65 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"anime-to-seerr-blocklist/internal/anime-list"
+	"anime-to-seerr-blocklist/internal/console"
+)
+
+// Placeholders the mapping uses in place of a TVDB ID
+const (
+	tvdbidHentai     = "hentai"
+	tvdbidOva        = "OVA"
+	tvdbidTvSpecial  = "tv special"
+	tvdbidWeb        = "web"
+	tvdbidMusicVideo = "music video"
+	tvdbidOther      = "other"
+)
+
+// isAdult reports whether p is restricted on AniDB, which the mapping records with the hentai placeholder
+func isAdult(p *AnimeList.Anime) bool {
+	return strings.EqualFold(p.Tvdbid, tvdbidHentai)
+}
+
+// isUnmappedSpecial reports whether p is a special release with no TVDB series to belong to
+func isUnmappedSpecial(p *AnimeList.Anime) bool {
+	for _, category := range []string{tvdbidOva, tvdbidTvSpecial, tvdbidWeb, tvdbidMusicVideo, tvdbidOther} {
+		if strings.EqualFold(p.Tvdbid, category) {
+			return true
+		}
+	}
+	return false
+}
+
+// droppedCategory reports whether p is in a category of anime that opts exclude from the blocklist
+func droppedCategory(opts *options, p *AnimeList.Anime) bool {
+	adult := isAdult(p)
+	if !opts.includeAdult && adult {
+		if opts.verbose {
+			console.Skipped("Skipping adult %s (%v)\n", p.Name, p.Tmdbtv)
+		}
+		return true
+	}
+	if opts.adultOnly && !adult {
+		return true
+	}
+	if opts.skipUnmappedSpecials && isUnmappedSpecial(p) {
+		if opts.verbose {
+			console.Skipped("Skipping special %s (%v)\n", p.Name, p.Tmdbtv)
+		}
+		return true
+	}
+	return false
+}
+
+func dropCategories(opts *options, fdp []AnimeList.Anime) []AnimeList.Anime {
+	if opts.includeAdult && !opts.adultOnly && !opts.skipUnmappedSpecials {
+		return fdp
+	}
+
+	return slices.DeleteFunc(fdp, func(p AnimeList.Anime) bool {
+		return droppedCategory(opts, &p)
+	})
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var commands = []string{"browse", "export", "list", "repair", "rollback", "simkl-login", "stats", "trakt-login", "serve-mock", "self-update", "service", "version", "completion"}
+
+// writeCompletion writes a script for shell that completes the commands and the flags of fs
+func writeCompletion(w io.Writer, shell string, fs *flag.FlagSet) error {
+	type flagInfo struct {
+		name, usage string
+	}
+	var flags []flagInfo
+	fs.VisitAll(func(f *flag.Flag) {
+		usage, _, _ := strings.Cut(f.Usage, "\n")
+		flags = append(flags, flagInfo{f.Name, usage})
+	})
+
+	switch shell {
+	case "bash":
+		words := make([]string, 0, len(flags)+len(commands))
+		for _, f := range flags {
+			words = append(words, "-"+f.name)
+		}
+		words = append(words, commands...)
+		fmt.Fprintf(w, `_anime_to_seerr_blocklist() {
+	COMPREPLY=($(compgen -W '%s' -- "${COMP_WORDS[COMP_CWORD]}"))
+}
+complete -o default -F _anime_to_seerr_blocklist anime-to-seerr-blocklist
+`, strings.Join(words, " "))
+	case "zsh":
+		fmt.Fprintln(w, "#compdef anime-to-seerr-blocklist")
+		fmt.Fprintln(w, "_arguments \\")
+		for _, f := range flags {
+			fmt.Fprintf(w, "\t'-%s[%s]' \\\n", f.name, zshEscape(f.usage))
+		}
+		fmt.Fprintf(w, "\t'1:command:(%s)' \\\n", strings.Join(commands, " "))
+		fmt.Fprintln(w, "\t'*:file:_files'")
+	case "fish":
+		for _, f := range flags {
+			fmt.Fprintf(w, "complete -c anime-to-seerr-blocklist -o %s -d '%s'\n", f.name, strings.ReplaceAll(f.usage, "'", `\'`))
+		}
+		fmt.Fprintf(w, "complete -c anime-to-seerr-blocklist -n __fish_use_subcommand -f -a '%s'\n", strings.Join(commands, " "))
+	default:
+		return fmt.Errorf("unknown shell %q, expected bash, zsh or fish", shell)
+	}
+	return nil
+}
+
+// zshEscape escapes s for a description within a single-quoted _arguments spec
+func zshEscape(s string) string {
+	return strings.NewReplacer("'", `'\''`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(s)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"anime-to-seerr-blocklist/internal/anime-list"
+)
+
+// Cross-references AniDB IDs with the IDs of other anime databases, built from anime-offline-database
+const crossrefURL = "https://raw.githubusercontent.com/Fribb/anime-lists/master/anime-list-mini.json"
+
+type crossrefEntry struct {
+	AnidbId   int    `json:"anidb_id"`
+	AnilistId int    `json:"anilist_id"`
+	MalId     int    `json:"mal_id"`
+	TmdbId    int    `json:"themoviedb_id"`
+	TvdbId    int    `json:"thetvdb_id"`
+	Type      string `json:"type"`
+}
+
+func fetchCrossref(ctx context.Context, cacheDir string, policy cachePolicy) ([]crossrefEntry, error) {
+	var entries []crossrefEntry
+
+	err := fetchCached(ctx, cacheDir, crossrefURL, policy, func(r io.Reader) error {
+		entries = nil
+		return json.NewDecoder(r).Decode(&entries)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// fetchAnidbIds returns a lookup from the ID of the database chosen by key to AniDB IDs
+func fetchAnidbIds(ctx context.Context, cacheDir string, policy cachePolicy, key func(e *crossrefEntry) int) (map[int]int, error) {
+	entries, err := fetchCrossref(ctx, cacheDir, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	anidbIds := make(map[int]int, len(entries))
+	for i := range entries {
+		if id := key(&entries[i]); id != 0 && entries[i].AnidbId != 0 {
+			anidbIds[id] = entries[i].AnidbId
+		}
+	}
+	return anidbIds, nil
+}
+
+// fetchOfflineDatabase returns the series the cross-reference maps to TMDB, with overrides applied. It has no titles,
+// so it's best merged after the anime-lists mapping to only fill in what that lacks
+func fetchOfflineDatabase(ctx context.Context, opts *options, overrides *mappingOverrides) ([]AnimeList.Anime, error) {
+	entries, err := fetchCrossref(ctx, opts.cacheDir, opts.mappingCache)
+	if err != nil {
+		return nil, err
+	}
+
+	var fdp []AnimeList.Anime
+	for _, e := range entries {
+		// A movie's TMDB ID isn't a series'
+		if e.AnidbId == 0 || e.TmdbId == 0 || strings.EqualFold(e.Type, "MOVIE") {
+			continue
+		}
+
+		p := AnimeList.Anime{Anidbid: e.AnidbId, Tmdbtv: e.TmdbId}
+		if e.TvdbId != 0 {
+			p.Tvdbid = strconv.Itoa(e.TvdbId)
+		}
+		if overrides.apply(&p) {
+			fdp = append(fdp, p)
+		}
+	}
+	return fdp, nil
+}
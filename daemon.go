@@ -0,0 +1,371 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Set in the environment of the runs a daemon starts, so they sync once instead of becoming daemons themselves
+const daemonChildEnv = "ANIME_TO_SEERR_BLOCKLIST_DAEMON_RUN"
+
+// daemon syncs on a schedule, or as files change with -watch. Each sync runs in a new process with the daemon's
+// arguments, so it reads the .env files afresh and can't take the daemon down with it when it fails
+type daemon struct {
+	opts *options
+	exe  string
+	// The flags to run each sync with
+	args    []string
+	environ []string
+	// Directory of the executable, to find .env files in
+	exeDir string
+	// Requests a sync now instead of waiting for the next
+	trigger chan struct{}
+
+	mu     sync.Mutex
+	status daemonStatus
+}
+
+type daemonStatus struct {
+	Running    bool      `json:"running"`
+	LastStart  time.Time `json:"lastStart,omitzero"`
+	LastFinish time.Time `json:"lastFinish,omitzero"`
+	LastError  string    `json:"lastError,omitempty"`
+	NextSync   time.Time `json:"nextSync,omitzero"`
+}
+
+func newDaemon(opts *options, exe string, args, environ []string, exeDir string) *daemon {
+	return &daemon{
+		opts:    opts,
+		exe:     exe,
+		args:    args,
+		environ: environ,
+		exeDir:  exeDir,
+		trigger: make(chan struct{}, 1),
+	}
+}
+
+// requestSync starts a sync as soon as the current one, if any, finishes
+func (d *daemon) requestSync() {
+	select {
+	case d.trigger <- struct{}{}:
+	default:
+		// Already requested
+	}
+}
+
+func (d *daemon) getStatus() daemonStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.status
+}
+
+func (d *daemon) updateStatus(update func(status *daemonStatus)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	update(&d.status)
+}
+
+// schedule is when the daemon syncs: every interval, or daily at syncAt in location if set, delayed by up to jitter
+type schedule struct {
+	interval time.Duration
+	syncAt   string
+	jitter   time.Duration
+	location *time.Location
+}
+
+// scheduleSettings override -interval, -sync-at, -schedule-jitter and -timezone, and unlike them are reloaded by SIGHUP
+var scheduleSettings = []string{"SYNC_INTERVAL", "SYNC_AT", "SCHEDULE_JITTER", "TIMEZONE"}
+
+// settings returns the values of keys as currently set in the environment the daemon was started with or, failing
+// that, the .env files
+func (d *daemon) settings(keys []string) (map[string]string, error) {
+	values := make(map[string]string, len(keys))
+	for _, kv := range d.environ {
+		if key, value, ok := strings.Cut(kv, "="); ok && slices.Contains(keys, key) {
+			values[key] = value
+		}
+	}
+	for _, f := range envFiles(d.opts.envFile, d.exeDir) {
+		if len(values) == len(keys) {
+			break
+		}
+		env, err := godotenv.Read(f)
+		if err != nil {
+			if d.opts.envFile != "" || !errors.Is(err, fs.ErrNotExist) {
+				return nil, &fs.PathError{Op: "load", Path: f, Err: err}
+			}
+			continue
+		}
+		for _, key := range keys {
+			if _, ok := values[key]; !ok {
+				if value, ok := env[key]; ok {
+					values[key] = value
+				}
+			}
+		}
+	}
+	return values, nil
+}
+
+// schedule returns the daemon's schedule: the flags it was started with, overridden by scheduleSettings where set
+func (d *daemon) schedule() (schedule, error) {
+	sched := schedule{
+		interval: d.opts.interval,
+		syncAt:   d.opts.syncAt,
+		jitter:   d.opts.scheduleJitter,
+		location: d.opts.location,
+	}
+	values, err := d.settings(scheduleSettings)
+	if err != nil {
+		return sched, err
+	}
+
+	if value := values["SYNC_INTERVAL"]; value != "" {
+		if sched.interval, err = time.ParseDuration(value); err != nil {
+			return sched, fmt.Errorf("$SYNC_INTERVAL: %w", err)
+		}
+		if sched.interval <= 0 {
+			return sched, errors.New("$SYNC_INTERVAL must be positive")
+		}
+	}
+	if value := values["SYNC_AT"]; value != "" {
+		if _, err = time.Parse("15:04", value); err != nil {
+			return sched, fmt.Errorf("$SYNC_AT must be HH:MM: %w", err)
+		}
+		sched.syncAt = value
+	}
+	if value := values["SCHEDULE_JITTER"]; value != "" {
+		if sched.jitter, err = time.ParseDuration(value); err != nil {
+			return sched, fmt.Errorf("$SCHEDULE_JITTER: %w", err)
+		}
+		if sched.jitter < 0 {
+			return sched, errors.New("$SCHEDULE_JITTER can't be negative")
+		}
+	}
+	if value := values["TIMEZONE"]; value != "" {
+		if sched.location, err = time.LoadLocation(value); err != nil {
+			return sched, fmt.Errorf("$TIMEZONE: %w", err)
+		}
+	}
+	if sched.location == nil {
+		sched.location = time.Local
+	}
+	return sched, nil
+}
+
+// String describes the schedule for the log
+func (s schedule) String() string {
+	var b strings.Builder
+	if s.syncAt != "" {
+		fmt.Fprintf(&b, "daily at %s %s", s.syncAt, s.location)
+	} else {
+		fmt.Fprintf(&b, "every %v", s.interval)
+	}
+	if s.jitter > 0 {
+		fmt.Fprintf(&b, ", delayed by up to %v", s.jitter)
+	}
+	return b.String()
+}
+
+// sync runs a single sync, as the daemon would have if it weren't given -daemon
+func (d *daemon) sync(ctx context.Context) error {
+	cmd := exec.CommandContext(ctx, d.exe, d.args...)
+	cmd.Env = append(d.environ, daemonChildEnv+"=1")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// Let the run save its progress when the daemon is stopped
+	if err := interruptible(cmd); err != nil {
+		return err
+	}
+	cmd.WaitDelay = time.Minute
+
+	return cmd.Run()
+}
+
+// runDaemon syncs with the flags args every interval until ctx is done, serving the dashboard and API if asked to
+func runDaemon(ctx context.Context, opts *options, args, environ []string, exeDir string) {
+	// Syncs lock the cache directory themselves, but a second daemon would still double every sync
+	if err := os.MkdirAll(opts.cacheDir, 0o755); err != nil {
+		log.Fatal(err)
+	}
+	lock, err := lockDaemon(opts.cacheDir)
+	if errors.Is(err, errLocked) {
+		log.Fatal("another daemon is already running for this cache directory")
+	} else if err != nil {
+		log.Fatal(err)
+	}
+	defer lock.Close()
+
+	self, err := os.Executable()
+	if err != nil {
+		log.Fatal(err)
+	}
+	d := newDaemon(opts, self, args, environ, exeDir)
+	if opts.apiListen != "" {
+		apiKey := os.Getenv("DAEMON_API_KEY")
+		if apiKey == "" {
+			log.Fatal("$DAEMON_API_KEY is required")
+		}
+		if err = (&daemonApi{d: d, apiKey: apiKey}).serve(ctx, opts.apiListen); err != nil {
+			log.Fatalf("-api-listen: %v", err)
+		}
+	}
+	if opts.watch {
+		files := watchedFiles(opts)
+		if len(files) == 0 {
+			log.Fatal("-watch has no local -allowlist, -mapping-file, -mapping-overrides or -user-allowlists files to watch")
+		}
+		if err = d.watch(ctx, files); err != nil {
+			log.Fatalf("-watch: %v", err)
+		}
+	}
+	d.run(ctx)
+}
+
+// nextSync returns when to sync after a sync started at lastStart, or first if lastStart is zero, as of now: at
+// sched's syncAt if set, otherwise interval after the last sync, delayed by up to its jitter. It's zero without -daemon,
+// when only -watch starts syncs
+func (d *daemon) nextSync(now, lastStart time.Time, sched schedule) time.Time {
+	var next time.Time
+	switch {
+	case lastStart.IsZero() && d.opts.runOnStart:
+		next = now
+	case !d.opts.daemon:
+		return time.Time{}
+	case sched.syncAt != "":
+		// Validated along with the rest of the schedule
+		at, _ := time.Parse("15:04", sched.syncAt)
+		next = dailyAt(now, 0, at, sched.location)
+		if !next.After(now) {
+			next = dailyAt(now, 1, at, sched.location)
+		}
+	case lastStart.IsZero():
+		next = now.Add(sched.interval)
+	default:
+		next = lastStart.Add(sched.interval)
+	}
+
+	if sched.jitter > 0 {
+		next = next.Add(rand.N(sched.jitter))
+	}
+	return next.Local()
+}
+
+// dailyAt returns at's time of day in location, days after now's day there. When the clocks going forward skip at,
+// it's taken to be as long after the change as at is into the skipped time, rather than as long before it as
+// time.Date would, which may have passed already and skip the day's sync
+func dailyAt(now time.Time, days int, at time.Time, location *time.Location) time.Time {
+	local := now.In(location)
+	t := time.Date(local.Year(), local.Month(), local.Day()+days, at.Hour(), at.Minute(), 0, 0, location)
+
+	// Minutes t's time of day is short of at's, if time.Date moved it back out of a gap
+	skipped := at.Hour()*60 + at.Minute() - (t.Hour()*60 + t.Minute())
+	if skipped < -12*60 {
+		skipped += 24 * 60
+	}
+	if skipped > 0 && skipped < 12*60 {
+		t = t.Add(time.Duration(skipped) * time.Minute)
+	}
+	return t
+}
+
+// run syncs on schedule until ctx is done. SIGHUP reloads the schedule without interrupting a sync, and only the
+// schedule: the daemon's other flags stay as started, while the rest of the .env files is read afresh by every sync
+// anyway
+func (d *daemon) run(ctx context.Context) {
+	sched, err := d.schedule()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var lastStart time.Time
+	first := d.nextSync(time.Now(), lastStart, sched)
+	d.updateStatus(func(status *daemonStatus) {
+		status.NextSync = first
+	})
+	if time.Until(first) > time.Second {
+		log.Printf("First sync at %s", first.Format(time.DateTime))
+	}
+	timer := time.NewTimer(time.Until(first))
+	defer timer.Stop()
+	if first.IsZero() {
+		timer.Stop()
+	}
+
+	runSync := func() {
+		lastStart = time.Now()
+		d.updateStatus(func(status *daemonStatus) {
+			status.Running = true
+			status.LastStart = lastStart
+		})
+		err := d.sync(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Error syncing: %v", err)
+		}
+		next := d.nextSync(time.Now(), lastStart, sched)
+		d.updateStatus(func(status *daemonStatus) {
+			status.Running = false
+			status.LastFinish = time.Now()
+			status.LastError = ""
+			if err != nil {
+				status.LastError = err.Error()
+			}
+			status.NextSync = next
+		})
+		if ctx.Err() != nil || next.IsZero() {
+			return
+		}
+		log.Printf("Next sync at %s", next.Format(time.DateTime))
+		timer.Reset(time.Until(next))
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			newSched, err := d.schedule()
+			if err != nil {
+				log.Printf("Error reloading configuration, keeping the current one: %v", err)
+				continue
+			}
+			if newSched.String() == sched.String() {
+				continue
+			}
+			sched = newSched
+			log.Printf("Syncing %s", sched)
+			// -run-on-start is about to start the first sync anyway
+			if !d.opts.daemon || (lastStart.IsZero() && d.opts.runOnStart) {
+				continue
+			}
+			next := d.nextSync(time.Now(), lastStart, sched)
+			d.updateStatus(func(status *daemonStatus) {
+				status.NextSync = next
+			})
+			log.Printf("Next sync at %s", next.Format(time.DateTime))
+			timer.Reset(time.Until(next))
+		case <-d.trigger:
+			runSync()
+		case <-timer.C:
+			runSync()
+		}
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+)
+
+// interruptible sets cmd, a sync, up to be stopped with os.Interrupt, as though by Ctrl+C
+func interruptible(cmd *exec.Cmd) error {
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	return nil
+}
+
+// The signal needs no help to reach a sync
+func stopWithDaemon(context.CancelFunc) {}
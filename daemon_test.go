@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextSync(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(value string) time.Time {
+		v, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		name       string
+		daemon     bool
+		runOnStart bool
+		now        string
+		lastStart  string
+		sched      schedule
+		want       string
+	}{
+		{"watch only", false, false, "2026-01-10T10:00:00Z", "", schedule{interval: time.Hour, location: time.UTC}, ""},
+		{"run on start", true, true, "2026-01-10T10:00:00Z", "", schedule{interval: time.Hour, location: time.UTC}, "2026-01-10T10:00:00Z"},
+		{"run on start without -daemon", false, true, "2026-01-10T10:00:00Z", "", schedule{interval: time.Hour, location: time.UTC}, "2026-01-10T10:00:00Z"},
+		{"first interval", true, false, "2026-01-10T10:00:00Z", "", schedule{interval: time.Hour, location: time.UTC}, "2026-01-10T11:00:00Z"},
+		{"interval after last start", true, true, "2026-01-10T10:20:00Z", "2026-01-10T10:00:00Z", schedule{interval: time.Hour, location: time.UTC}, "2026-01-10T11:00:00Z"},
+		{"interval overdue", true, true, "2026-01-10T12:00:00Z", "2026-01-10T10:00:00Z", schedule{interval: time.Hour, location: time.UTC}, "2026-01-10T11:00:00Z"},
+		{"sync-at later today", true, false, "2026-01-10T10:00:00Z", "", schedule{syncAt: "12:00", location: time.UTC}, "2026-01-10T12:00:00Z"},
+		{"sync-at passed today", true, false, "2026-01-10T13:00:00Z", "", schedule{syncAt: "12:00", location: time.UTC}, "2026-01-11T12:00:00Z"},
+		{"sync-at now", true, true, "2026-01-10T12:00:00Z", "2026-01-10T12:00:00Z", schedule{syncAt: "12:00", location: time.UTC}, "2026-01-11T12:00:00Z"},
+		{"sync-at ignores last start", true, true, "2026-01-10T10:00:00Z", "2026-01-10T09:59:00Z", schedule{syncAt: "12:00", interval: time.Hour, location: time.UTC}, "2026-01-10T12:00:00Z"},
+		// 08:30 on the 11th in Tokyo
+		{"timezone past midnight", true, false, "2026-01-10T23:30:00Z", "", schedule{syncAt: "03:30", location: tokyo}, "2026-01-12T03:30:00+09:00"},
+		{"timezone before midnight here", true, false, "2026-01-10T23:30:00Z", "", schedule{syncAt: "09:00", location: tokyo}, "2026-01-11T09:00:00+09:00"},
+		{"sync-at before midnight", true, false, "2026-01-10T23:59:00Z", "", schedule{syncAt: "23:58", location: time.UTC}, "2026-01-11T23:58:00Z"},
+		{"sync-at just after midnight", true, false, "2026-01-10T23:59:00Z", "", schedule{syncAt: "00:00", location: time.UTC}, "2026-01-11T00:00:00Z"},
+		// The clocks go from 02:00 EST to 03:00 EDT on March 8th 2026, and back from 02:00 EDT to 01:00 EST on November 1st
+		{"DST starts", true, false, "2026-03-07T12:00:00-05:00", "", schedule{syncAt: "04:00", location: newYork}, "2026-03-08T04:00:00-04:00"},
+		{"DST skips sync-at", true, false, "2026-03-07T12:00:00-05:00", "", schedule{syncAt: "02:30", location: newYork}, "2026-03-08T03:30:00-04:00"},
+		{"DST skipped sync-at after the change", true, false, "2026-03-08T01:45:00-05:00", "", schedule{syncAt: "02:30", location: newYork}, "2026-03-08T03:30:00-04:00"},
+		{"day after DST skipped sync-at", true, false, "2026-03-08T03:30:00-04:00", "", schedule{syncAt: "02:30", location: newYork}, "2026-03-09T02:30:00-04:00"},
+		{"DST ends", true, false, "2026-10-31T12:00:00-04:00", "", schedule{syncAt: "04:00", location: newYork}, "2026-11-01T04:00:00-05:00"},
+		{"DST repeats sync-at", true, false, "2026-10-31T12:00:00-04:00", "", schedule{syncAt: "01:30", location: newYork}, "2026-11-01T01:30:00-04:00"},
+		{"DST repeated sync-at only once", true, false, "2026-11-01T01:30:00-04:00", "", schedule{syncAt: "01:30", location: newYork}, "2026-11-02T01:30:00-05:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &daemon{opts: &options{daemon: tt.daemon, runOnStart: tt.runOnStart}}
+			var lastStart time.Time
+			if tt.lastStart != "" {
+				lastStart = at(tt.lastStart)
+			}
+
+			got := d.nextSync(at(tt.now), lastStart, tt.sched)
+			if tt.want == "" {
+				if !got.IsZero() {
+					t.Errorf("nextSync() = %v, want zero", got)
+				}
+				return
+			}
+			if want := at(tt.want); !got.Equal(want) {
+				t.Errorf("nextSync() = %v, want %v", got, want.In(tt.sched.location))
+			}
+		})
+	}
+}
+
+func TestNextSyncJitter(t *testing.T) {
+	d := &daemon{opts: &options{daemon: true}}
+	now := time.Date(2026, 1, 10, 10, 0, 0, 0, time.UTC)
+	for _, sched := range []schedule{
+		{interval: time.Hour, jitter: time.Minute, location: time.UTC},
+		{syncAt: "11:00", jitter: time.Minute, location: time.UTC},
+	} {
+		want := now.Add(time.Hour)
+		for range 100 {
+			if got := d.nextSync(now, time.Time{}, sched); got.Before(want) || !got.Before(want.Add(sched.jitter)) {
+				t.Fatalf("nextSync(%v) = %v, want within %v of %v", sched, got, sched.jitter, want)
+			}
+		}
+	}
+}
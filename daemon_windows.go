@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// interruptible sets cmd, a sync, up to be stopped through a pipe to its stdin, as Windows has no os.Interrupt to send
+// it. The sync also stops should the daemon die, closing the pipe
+func interruptible(cmd *exec.Cmd) error {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	cmd.Cancel = stdin.Close
+	return nil
+}
+
+// stopWithDaemon calls cancel once the daemon that started this sync closes its stdin
+func stopWithDaemon(cancel context.CancelFunc) {
+	go func() {
+		_, _ = io.Copy(io.Discard, os.Stdin)
+		cancel()
+	}()
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+
+	"anime-to-seerr-blocklist/pkg/blocklistsync"
+)
+
+// daemonApi lets other automation trigger and inspect the daemon's syncs over HTTP
+type daemonApi struct {
+	d      *daemon
+	apiKey string
+}
+
+func (a *daemonApi) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", a.dashboard)
+	mux.HandleFunc("POST /sync", a.sync)
+	mux.HandleFunc("GET /status", a.status)
+	mux.HandleFunc("GET /queue", a.queue)
+	// Browsers send basic auth along with forms posted from other sites, so those are refused
+	return http.NewCrossOriginProtection().Handler(a.authenticate(mux))
+}
+
+// authenticate requires the API key as either X-Api-Key, a bearer token or, for browsers, the password of basic auth
+func (a *daemonApi) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-Api-Key")
+		if key == "" {
+			key, _ = strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		}
+		if _, password, ok := r.BasicAuth(); ok {
+			key = password
+		}
+		if subtle.ConstantTimeCompare([]byte(key), []byte(a.apiKey)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="anime-to-seerr-blocklist", charset="UTF-8"`)
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid API key"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *daemonApi) sync(w http.ResponseWriter, r *http.Request) {
+	a.d.requestSync()
+	if r.Header.Get("Content-Type") == "application/x-www-form-urlencoded" {
+		// From the dashboard
+		http.Redirect(w, r, "./", http.StatusSeeOther)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, a.d.getStatus())
+}
+
+func (a *daemonApi) status(w http.ResponseWriter, _ *http.Request) {
+	run, err := loadLastRun(a.d.opts.cacheDir)
+	if err != nil {
+		log.Printf("Error reading last sync report: %v", err)
+	}
+	writeJSON(w, http.StatusOK, struct {
+		daemonStatus
+		LastRun *lastRun `json:"lastRun"`
+	}{a.d.getStatus(), run})
+}
+
+func (a *daemonApi) queue(w http.ResponseWriter, _ *http.Request) {
+	retries, err := blocklistSync.PendingRetries(a.d.opts.cacheDir)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, retries)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// serve serves the API at addr until ctx is done
+func (a *daemonApi) serve(ctx context.Context, addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{Handler: a.handler()}
+	context.AfterFunc(ctx, func() {
+		_ = srv.Shutdown(context.Background())
+	})
+
+	log.Printf("Serving the daemon API at http://%s", l.Addr())
+	go func() {
+		if err := srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Error serving the daemon API: %v", err)
+		}
+	}()
+	return nil
+}
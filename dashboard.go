@@ -0,0 +1,78 @@
+package main
+
+import (
+	_ "embed"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+
+	"anime-to-seerr-blocklist/pkg/blocklistsync"
+)
+
+//go:embed web/dashboard.html
+var dashboardHtml string
+
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(dashboardHtml))
+
+const dashboardConflicts = 20
+const dashboardHistory = 20
+
+type dashboardFilter struct {
+	Name, Value string
+}
+
+// filters lists the settings that decide which anime are blocklisted
+func (o *options) filters() []dashboardFilter {
+	filters := []dashboardFilter{
+		{"Sources", o.sources},
+		{"Adult anime", strconv.FormatBool(o.includeAdult)},
+		{"Only adult anime", strconv.FormatBool(o.adultOnly)},
+		{"Skip unmapped specials", strconv.FormatBool(o.skipUnmappedSpecials)},
+		{"Skip mixed series", strconv.FormatBool(o.skipMixedSeries)},
+	}
+	if o.allowPopularAbove > 0 {
+		filters = append(filters, dashboardFilter{"Allow popular above", strconv.FormatFloat(o.allowPopularAbove, 'g', -1, 64) + " " + o.popularityMetric})
+	}
+	if o.allowlistSonarr != "" {
+		filters = append(filters, dashboardFilter{"Allowlist Sonarr series", o.allowlistSonarr})
+	}
+	if o.maxAdditions > 0 {
+		filters = append(filters, dashboardFilter{"Max additions per sync", strconv.Itoa(o.maxAdditions)})
+	}
+	return filters
+}
+
+// dashboard shows what the daemon has been doing, for those who'd rather not use the API or the command line
+func (a *daemonApi) dashboard(w http.ResponseWriter, _ *http.Request) {
+	data := struct {
+		Status    daemonStatus
+		LastRun   *lastRun
+		Filters   []dashboardFilter
+		Retries   []blocklistSync.Retry
+		Conflicts []blocklistSync.Conflict
+		History   []historyEntry
+	}{
+		Status:  a.d.getStatus(),
+		Filters: a.d.opts.filters(),
+	}
+
+	var err error
+	if data.LastRun, err = loadLastRun(a.d.opts.cacheDir); err != nil {
+		log.Printf("Error reading last sync report: %v", err)
+	}
+	if data.Retries, err = blocklistSync.PendingRetries(a.d.opts.cacheDir); err != nil {
+		log.Printf("Error reading retry queue: %v", err)
+	}
+	if data.Conflicts, err = blocklistSync.RecentConflicts(a.d.opts.cacheDir, dashboardConflicts); err != nil {
+		log.Printf("Error reading conflicts: %v", err)
+	}
+	if data.History, err = recentHistory(a.d.opts.cacheDir, dashboardHistory); err != nil {
+		log.Printf("Error reading history: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err = dashboardTemplate.Execute(w, &data); err != nil {
+		log.Printf("Error rendering dashboard: %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"anime-to-seerr-blocklist/internal/console"
+	"anime-to-seerr-blocklist/internal/seerr"
+)
+
+// TMDB keyword "anime"
+const animeKeywordId = "210024"
+
+// addDiscoverKeyword adds the anime keyword to Seerr's blocklisted tags, which keeps anime out of Discover with a single
+// settings change
+func addDiscoverKeyword(ctx context.Context, seerrSettingsClient *seerrApi.Client, verbose bool) error {
+	var settings seerrApi.MainSettings
+	if err := seerrSettingsClient.Get(ctx, "/main", nil, &settings); err != nil {
+		return err
+	}
+
+	var tags []string
+	if settings.BlocklistedTags != "" {
+		tags = strings.Split(settings.BlocklistedTags, ",")
+		for _, tag := range tags {
+			if strings.TrimSpace(tag) == animeKeywordId {
+				return nil
+			}
+		}
+	}
+
+	if verbose {
+		console.Added("Adding keyword %s to blocklisted tags\n", animeKeywordId)
+	}
+	// Seerr merges the posted fields into its existing settings
+	return seerrSettingsClient.Post(ctx, "/main", nil, &seerrApi.MainSettings{
+		BlocklistedTags: strings.Join(append(tags, animeKeywordId), ","),
+	}, nil)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"anime-to-seerr-blocklist/internal/seerr"
+)
+
+// Set for the test binary to run as the tool itself, so the end-to-end tests don't need to build it
+const e2eMainEnv = "ANIME_TO_SEERR_BLOCKLIST_E2E_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(e2eMainEnv) != "" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runTool returns a command running the tool with args
+func runTool(t *testing.T, env []string, args ...string) *exec.Cmd {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.CommandContext(t.Context(), exe, args...)
+	cmd.Env = append(os.Environ(), e2eMainEnv+"=1")
+	cmd.Env = append(cmd.Env, env...)
+	return cmd
+}
+
+// startMock runs serve-mock with args, returning its URL
+func startMock(t *testing.T, args ...string) string {
+	t.Helper()
+	cmd := runTool(t, nil, append([]string{"serve-mock", "-listen", "127.0.0.1:0"}, args...)...)
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		if _, after, ok := strings.Cut(scanner.Text(), "listening on "); ok {
+			host, _, _ := strings.Cut(after, " ")
+			go func() {
+				// Keep the mock from blocking on a full pipe
+				for scanner.Scan() {
+				}
+			}()
+			return host
+		}
+	}
+	t.Fatal("serve-mock exited without listening")
+	return ""
+}
+
+func TestSyncAgainstMock(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the tool")
+	}
+
+	host := startMock(t, "-seed-movies", "5")
+	cacheDir := t.TempDir()
+	envFile := filepath.Join(cacheDir, "empty.env")
+	if err := os.WriteFile(envFile, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := runTool(t, []string{"SEERR_HOST=" + host, "SEERR_API_KEY=mock", "SEERR_USER_ID=1"},
+		"-cache-dir", cacheDir, "-env-file", envFile, "-offline", "-mapping-file", filepath.Join("testdata", "anime-list.xml"))
+	var output bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &output, &output
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("sync failed: %v\n%s", err, output.Bytes())
+	}
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, host+"/api/v1/blocklist?take=50", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Api-Key", "mock")
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var blocklist seerrApi.GetBlocklistResponse
+	if err = json.NewDecoder(resp.Body).Decode(&blocklist); err != nil {
+		t.Fatal(err)
+	}
+
+	// The movie seeded with the ID of a series is kept, as no sync added it
+	got := make(map[int]seerrApi.MediaType)
+	for _, entry := range blocklist.Results {
+		got[entry.TmdbId] = entry.MediaType
+	}
+	want := map[int]seerrApi.MediaType{1001: seerrApi.MediaTypeTv, 1002: seerrApi.MediaTypeTv, 1003: seerrApi.MediaTypeTv, 5: seerrApi.MediaTypeMovie}
+	if !maps.Equal(got, want) {
+		t.Errorf("blocklist = %v, want %v\n%s", got, want, output.Bytes())
+	}
+
+	b, err := os.ReadFile(filepath.Join(cacheDir, lastRunFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last lastRun
+	if err = json.Unmarshal(b, &last); err != nil {
+		t.Fatal(err)
+	}
+	if report := last.Report; report.Added != 3 || report.Conflicts != 1 || !report.Finished {
+		t.Errorf("report = %+v, want 3 added and 1 conflict, finished", report)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+// envFiles returns the .env files to load, in order of precedence: envFile if given, otherwise the working directory's,
+// the user config directory's and the executable's
+func envFiles(envFile, exe string) []string {
+	if envFile != "" {
+		return []string{envFile}
+	}
+
+	files := []string{".env"}
+	if configDir, err := os.UserConfigDir(); err == nil {
+		files = append(files, filepath.Join(configDir, "anime-to-seerr-blocklist", ".env"))
+	}
+	return append(files, filepath.Join(exe, ".env"))
+}
+
+// addHeader adds a "Name: value" header to h
+func addHeader(h http.Header, header string) error {
+	name, value, ok := strings.Cut(header, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return fmt.Errorf("header %q isn't of the form \"Name: value\"", header)
+	}
+	h.Add(name, strings.TrimSpace(value))
+	return nil
+}
+
+// loadEnv loads the variables from the .env files that exist. Variables already set aren't overridden, so a file's
+// variables take precedence over those of the files after it
+func loadEnv(envFile, exe string) error {
+	for _, f := range envFiles(envFile, exe) {
+		if err := godotenv.Load(f); err != nil && (envFile != "" || !errors.Is(err, fs.ErrNotExist)) {
+			return &fs.PathError{Op: "load", Path: f, Err: err}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+
+	"anime-to-seerr-blocklist/internal/anime-list"
+)
+
+// writeExclusions writes the mapping as CSV rows of the ID Sonarr (TVDB) or Radarr (TMDB) exclude by and a title
+func writeExclusions(w io.Writer, format string, fdp []AnimeList.Anime) error {
+	cw := csv.NewWriter(w)
+	seen := make(map[int]struct{})
+
+	switch format {
+	case "sonarr-exclusions":
+		if err := cw.Write([]string{"tvdbId", "title"}); err != nil {
+			return err
+		}
+		for i := range fdp {
+			tvdbId, ok := seriesTvdbId(&fdp[i])
+			if !ok {
+				continue
+			}
+			if _, ok = seen[tvdbId]; ok {
+				continue
+			}
+			seen[tvdbId] = struct{}{}
+			if err := cw.Write([]string{strconv.Itoa(tvdbId), fdp[i].Name}); err != nil {
+				return err
+			}
+		}
+	case "radarr-exclusions":
+		if err := cw.Write([]string{"tmdbId", "movieTitle"}); err != nil {
+			return err
+		}
+		for i := range fdp {
+			for _, tmdbId := range movieTmdbIds(&fdp[i]) {
+				if _, ok := seen[tmdbId]; ok {
+					continue
+				}
+				seen[tmdbId] = struct{}{}
+				if err := cw.Write([]string{strconv.Itoa(tmdbId), fdp[i].Name}); err != nil {
+					return err
+				}
+			}
+		}
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func runExport(ctx context.Context, opts *options, args []string) {
+	var format, output string
+
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	fs.StringVar(&format, "format", "sonarr-exclusions", "Export format: sonarr-exclusions or radarr-exclusions")
+	fs.StringVar(&output, "o", "-", "File to write to, or - for stdout")
+	_ = fs.Parse(args)
+
+	if format != "sonarr-exclusions" && format != "radarr-exclusions" {
+		log.Fatalf("unknown format %q", format)
+	}
+
+	buildAllowlist(ctx, opts)
+	fdp, err := loadMapping(ctx, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	w := os.Stdout
+	if output != "-" {
+		w, err = os.Create(output)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer w.Close()
+	}
+
+	if err = writeExclusions(w, format, fdp); err != nil {
+		log.Fatal(err)
+	}
+}
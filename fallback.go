@@ -0,0 +1,17 @@
+package main
+
+import (
+	"embed"
+	"io/fs"
+	"path"
+)
+
+// Snapshots of the anime mappings, present if downloaded by `make fallback` before building, as `make release` does
+//
+//go:embed fallback
+var fallbackFS embed.FS
+
+// openFallback opens the embedded snapshot of the file at rawUrl
+func openFallback(rawUrl string) (fs.File, error) {
+	return fallbackFS.Open(path.Join("fallback", path.Base(rawUrl)))
+}
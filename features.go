@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"anime-to-seerr-blocklist/internal/atomicfile"
+	"anime-to-seerr-blocklist/internal/seerr"
+)
+
+const seerrServerFile = "seerr-server.json"
+
+// What's known about a server is trusted for this long, as a development build can change under the same version
+// string, and bulk blocklisting can't be told from the version
+const seerrServerMaxAge = 24 * time.Hour
+
+// seerrServer is what the Seerr instance is and which of the features used here it has. The features are inferred
+// from the version where it can be parsed, and otherwise probed for, as forks and development builds don't number
+// consistently
+type seerrServer struct {
+	Host          string    `json:"host"`
+	Version       string    `json:"version"`
+	Blocklist     bool      `json:"blocklist"`
+	OverrideRules bool      `json:"overrideRules"`
+	BulkBlocklist bool      `json:"bulkBlocklist"`
+	Probed        time.Time `json:"probed"`
+}
+
+// seerrFeatureVersions are the first releases with each feature. Seerr's numbering carries on from Jellyseerr's,
+// which added override rules, while the blocklist took its name and path in Seerr itself. Overseerr's 1.x releases
+// have neither
+var seerrFeatureVersions = struct {
+	blocklist, overrideRules [3]int
+}{
+	blocklist:     [3]int{3, 0, 0},
+	overrideRules: [3]int{2, 2, 0},
+}
+
+// atLeast reports whether the version major.minor.patch is first or later
+func atLeast(major, minor, patch int, first [3]int) bool {
+	return slices.Compare([]int{major, minor, patch}, first[:]) >= 0
+}
+
+// parseVersion parses a version like "2.7.3" or "v1.34.0-develop", ignoring any suffix
+func parseVersion(version string) (major, minor, patch int, ok bool) {
+	version, _, _ = strings.Cut(strings.TrimPrefix(version, "v"), "-")
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) != 3 {
+		return 0, 0, 0, false
+	}
+
+	var err error
+	if major, err = strconv.Atoi(parts[0]); err != nil {
+		return 0, 0, 0, false
+	}
+	if minor, err = strconv.Atoi(parts[1]); err != nil {
+		return 0, 0, 0, false
+	}
+	if patch, err = strconv.Atoi(parts[2]); err != nil {
+		return 0, 0, 0, false
+	}
+	return major, minor, patch, true
+}
+
+// hasEndpoint reports whether GETting the client's endpoint doesn't 404
+func hasEndpoint(ctx context.Context, client *seerrApi.Client, queryParams url.Values) (bool, error) {
+	var resp any
+	err := client.Get(ctx, "", queryParams, &resp)
+	if httpErr, ok := errors.AsType[*seerrApi.HTTPError](err); ok && httpErr.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// detectSeerrServer returns what the server at host is. Features its version can't tell, which with an unknown
+// version is all of them and otherwise only bulk blocklisting, are probed for unless they were recently, as recorded
+// in cacheDir
+func detectSeerrServer(ctx context.Context, seerr *seerrApi.Client, cacheDir, host string) (*seerrServer, error) {
+	var status seerrApi.GetStatusResponse
+	if err := seerr.Status().Get(ctx, "", nil, &status); err != nil {
+		return nil, fmt.Errorf("failed to get server status: %w", err)
+	}
+
+	filename := filepath.Join(cacheDir, seerrServerFile)
+	if b, err := os.ReadFile(filename); err == nil {
+		var cached seerrServer
+		if err = json.Unmarshal(b, &cached); err == nil && cached.Host == host && cached.Version == status.Version &&
+			time.Since(cached.Probed) < seerrServerMaxAge {
+			return &cached, nil
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	server := &seerrServer{Host: host, Version: status.Version}
+	var err error
+	if major, minor, patch, ok := parseVersion(status.Version); ok {
+		server.Blocklist = atLeast(major, minor, patch, seerrFeatureVersions.blocklist)
+		server.OverrideRules = atLeast(major, minor, patch, seerrFeatureVersions.overrideRules)
+	} else {
+		if server.Blocklist, err = hasEndpoint(ctx, seerr.Blocklist(), url.Values{"take": []string{"1"}}); err != nil {
+			return nil, err
+		}
+		if server.OverrideRules, err = hasEndpoint(ctx, seerr.OverrideRules(), nil); err != nil {
+			return nil, err
+		}
+	}
+	if server.Blocklist {
+		server.BulkBlocklist = hasBulkBlocklist(ctx, seerr.Blocklist())
+	}
+	server.Probed = time.Now()
+
+	b, err := json.Marshal(server)
+	if err != nil {
+		return nil, err
+	}
+	if err = atomicFile.WriteFile(filename, b); err != nil {
+		log.Printf("Error saving server features: %v", err)
+	}
+	return server, nil
+}
+
+// hasBulkBlocklist reports whether the blocklist has a bulk endpoint, which no release of Seerr has yet, by posting it
+// an empty batch. Failing that, series are added one at a time
+func hasBulkBlocklist(ctx context.Context, seerrBlocklistClient *seerrApi.Client) bool {
+	err := seerrBlocklistClient.Post(ctx, "/bulk", nil, &seerrApi.PostBlocklistBulkJSONRequestBody{Items: []seerrApi.PostBlocklistJSONRequestBody{}}, nil)
+	return err == nil
+}
+
+// versionString is the server's version for messages, saying so if it's unknown
+func (s *seerrServer) versionString() string {
+	if _, _, _, ok := parseVersion(s.Version); !ok {
+		return "(unknown version)"
+	}
+	return s.Version
+}
+
+// checkFeatures returns an error for the first of opts that needs a feature the server doesn't have
+func (s *seerrServer) checkFeatures(opts *options) error {
+	if opts.overrideSonarrId >= 0 && !s.OverrideRules {
+		return s.tooOld("-override-sonarr-id", "override rules")
+	}
+	if opts.verifyDrift && !s.Blocklist {
+		return s.tooOld("-verify-drift", "blocklist")
+	}
+	return nil
+}
+
+func (s *seerrServer) tooOld(option, feature string) error {
+	return fmt.Errorf("your server, version %s, is too old for %s: it has no %s", s.versionString(), option, feature)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"anime-to-seerr-blocklist/internal/seerr"
+)
+
+func TestDetectSeerrServer(t *testing.T) {
+	tests := []struct {
+		version string
+		// hasEndpoints is whether the server answers the probes, and wantProbes whether it should be probed for more
+		// than bulk blocklisting
+		hasEndpoints       bool
+		wantBlocklist      bool
+		wantOverrideRules  bool
+		wantBulk           bool
+		wantProbes         bool
+		wantVersionMessage string
+	}{
+		{"3.0.1", false, true, true, false, false, "3.0.1"},
+		{"3.0.1", true, true, true, true, false, "3.0.1"},
+		{"v2.7.3", true, false, true, false, false, "v2.7.3"},
+		{"1.34.0", true, false, false, false, false, "1.34.0"},
+		{"develop", true, true, true, true, true, "(unknown version)"},
+		{"develop", false, false, false, false, true, "(unknown version)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			var probes, bulkProbes atomic.Int32
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /api/v1/status", func(w http.ResponseWriter, _ *http.Request) {
+				_ = json.NewEncoder(w).Encode(map[string]string{"version": tt.version})
+			})
+			probe := func(w http.ResponseWriter, _ *http.Request) {
+				probes.Add(1)
+				if !tt.hasEndpoints {
+					http.NotFound(w, nil)
+					return
+				}
+				_, _ = w.Write([]byte("{}"))
+			}
+			mux.HandleFunc("GET /api/v1/blocklist", probe)
+			mux.HandleFunc("GET /api/v1/overrideRule", probe)
+			if tt.hasEndpoints {
+				mux.HandleFunc("POST /api/v1/blocklist/bulk", func(w http.ResponseWriter, _ *http.Request) {
+					bulkProbes.Add(1)
+					w.WriteHeader(http.StatusCreated)
+				})
+			}
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			seerr, err := seerrApi.NewClient(server.URL, "key")
+			if err != nil {
+				t.Fatal(err)
+			}
+			cacheDir := t.TempDir()
+			for range 2 {
+				got, err := detectSeerrServer(t.Context(), seerr, cacheDir, server.URL)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got.Blocklist != tt.wantBlocklist || got.OverrideRules != tt.wantOverrideRules || got.BulkBlocklist != tt.wantBulk {
+					t.Errorf("blocklist, override rules, bulk = %v, %v, %v, want %v, %v, %v", got.Blocklist, got.OverrideRules,
+						got.BulkBlocklist, tt.wantBlocklist, tt.wantOverrideRules, tt.wantBulk)
+				}
+				if got.versionString() != tt.wantVersionMessage {
+					t.Errorf("version = %q, want %q", got.versionString(), tt.wantVersionMessage)
+				}
+			}
+
+			// Once for each endpoint, the second detection using what the first found
+			if probed := probes.Load() > 0; probed != tt.wantProbes || probes.Load() > 2 {
+				t.Errorf("probed %d times, want probing %v once", probes.Load(), tt.wantProbes)
+			}
+			if bulkProbes.Load() > 1 {
+				t.Errorf("probed for bulk blocklisting %d times, want at most once", bulkProbes.Load())
+			}
+		})
+	}
+}
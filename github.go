@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"anime-to-seerr-blocklist/internal/rest"
+)
+
+// latestGithubCommit returns the SHA of the last commit to change the file at a raw.githubusercontent.com URL
+func latestGithubCommit(ctx context.Context, rawUrl string) (string, error) {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return "", err
+	}
+	// /{owner}/{repo}/{branch}/{path}
+	parts := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 4)
+	if u.Host != "raw.githubusercontent.com" || len(parts) != 4 {
+		return "", errors.New("not a raw GitHub URL")
+	}
+
+	apiUrl := url.URL{
+		Scheme: "https",
+		Host:   "api.github.com",
+		Path:   fmt.Sprintf("/repos/%s/%s/commits", parts[0], parts[1]),
+		RawQuery: url.Values{
+			"sha":      []string{parts[2]},
+			"path":     []string{parts[3]},
+			"per_page": []string{"1"},
+		}.Encode(),
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiUrl.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", restApi.UserAgent)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	var commits []struct {
+		Sha string `json:"sha"`
+	}
+	if err = json.NewDecoder(restApi.LimitReader(resp.Body, restApi.MaxResponseSize)).Decode(&commits); err != nil {
+		return "", err
+	}
+	if len(commits) == 0 {
+		return "", errors.New("no commits found")
+	}
+	return commits[0].Sha, nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"anime-to-seerr-blocklist/pkg/blocklistsync"
+)
+
+const historyFile = "history.csv"
+
+var historyHeader = []string{"time", "durationSeconds", "mappingEntries", "blocklisted", "added", "unblocked", "failed", "conflicts", "finished"}
+
+// appendHistory adds a row of the sync's metrics to a CSV file in cacheDir, to graph the blocklist's growth over time
+// without any monitoring set up
+func appendHistory(cacheDir string, start time.Time, mappingEntries int, report *blocklistSync.Report) error {
+	filename := filepath.Join(cacheDir, historyFile)
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	cw := csv.NewWriter(f)
+	if fi, err := f.Stat(); err == nil && fi.Size() == 0 {
+		if err = cw.Write(historyHeader); err != nil {
+			return err
+		}
+	}
+
+	// An incremental sync with nothing to do doesn't fetch the blocklist, so its size isn't known
+	blocklisted := ""
+	if !report.UpToDate {
+		blocklisted = strconv.Itoa(report.Blocklisted)
+	}
+	if err = cw.Write([]string{
+		start.UTC().Format(time.RFC3339),
+		strconv.FormatFloat(time.Since(start).Seconds(), 'f', 1, 64),
+		strconv.Itoa(mappingEntries),
+		blocklisted,
+		strconv.Itoa(report.Added),
+		strconv.Itoa(report.Unblocked),
+		strconv.Itoa(report.Failed),
+		strconv.Itoa(report.Conflicts),
+		strconv.FormatBool(report.Finished),
+	}); err != nil {
+		return err
+	}
+
+	cw.Flush()
+	if err = cw.Error(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// historyEntry is a row of the history, as shown on the dashboard
+type historyEntry struct {
+	Time                                             time.Time
+	Blocklisted, Added, Unblocked, Failed, Conflicts string
+	Finished                                         bool
+}
+
+// recentHistory returns up to the last n syncs recorded by appendHistory, newest first
+func recentHistory(cacheDir string, n int) ([]historyEntry, error) {
+	f, err := os.Open(filepath.Join(cacheDir, historyFile))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	cr := csv.NewReader(f)
+	cr.FieldsPerRecord = -1
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []historyEntry
+	for _, record := range records {
+		// Skip the header, and rows torn by a crash mid-write
+		if len(record) != len(historyHeader) || record[0] == historyHeader[0] {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, record[0])
+		if err != nil {
+			continue
+		}
+		finished, _ := strconv.ParseBool(record[8])
+		entries = append(entries, historyEntry{
+			Time:        t,
+			Blocklisted: record[3],
+			Added:       record[4],
+			Unblocked:   record[5],
+			Failed:      record[6],
+			Conflicts:   record[7],
+			Finished:    finished,
+		})
+	}
+
+	entries = entries[max(0, len(entries)-n):]
+	slices.Reverse(entries)
+	return entries, nil
+}
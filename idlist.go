@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"anime-to-seerr-blocklist/internal/anime-list"
+)
+
+// isIdListSource reports whether source is the URL of a list of TMDB IDs rather than the name of a source
+func isIdListSource(source string) bool {
+	return isUrl(source)
+}
+
+// fetchIdList returns the series on the list of TMDB IDs at rawUrl, which is cached like the mapping
+func fetchIdList(ctx context.Context, opts *options, rawUrl string) ([]AnimeList.Anime, error) {
+	var fdp []AnimeList.Anime
+	err := fetchCached(ctx, opts.cacheDir, rawUrl, opts.mappingCache, func(r io.Reader) error {
+		var err error
+		fdp, err = parseIdList(r)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", rawUrl, err)
+	}
+	return fdp, nil
+}
+
+// parseIdList reads a list of TMDB IDs. Each line is a series' ID, optionally followed by its title. Blank lines and
+// those starting with # are ignored
+func parseIdList(r io.Reader) ([]AnimeList.Anime, error) {
+	var fdp []AnimeList.Anime
+
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		tmdbId, err := strconv.Atoi(fields[0])
+		if err != nil || tmdbId <= 0 {
+			return nil, fmt.Errorf("line %d: %q isn't a TMDB ID", lineNo, fields[0])
+		}
+		title := strings.TrimSpace(strings.TrimPrefix(strings.Join(fields[1:], " "), "#"))
+		fdp = append(fdp, AnimeList.Anime{Tmdbtv: tmdbId, Name: title})
+	}
+
+	return fdp, scanner.Err()
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"anime-to-seerr-blocklist/internal/anime-list"
+)
+
+func TestParseIdList(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    string
+		want    []AnimeList.Anime
+		wantErr bool
+	}{
+		{"empty", "", nil, false},
+		{"IDs", "1001\n1002\n", []AnimeList.Anime{{Tmdbtv: 1001}, {Tmdbtv: 1002}}, false},
+		{"no trailing newline", "1001\n1002", []AnimeList.Anime{{Tmdbtv: 1001}, {Tmdbtv: 1002}}, false},
+		{"CRLF", "1001\r\n1002\r\n", []AnimeList.Anime{{Tmdbtv: 1001}, {Tmdbtv: 1002}}, false},
+		{"titles", "1001 Show One\n1002\tShow  Two\n", []AnimeList.Anime{{Tmdbtv: 1001, Name: "Show One"}, {Tmdbtv: 1002, Name: "Show Two"}}, false},
+		{"titles as comments", "1001 # Show One\n", []AnimeList.Anime{{Tmdbtv: 1001, Name: "Show One"}}, false},
+		{"comments and blank lines", "# Anime\n\n  1001\n   \n# 1002\n", []AnimeList.Anime{{Tmdbtv: 1001}}, false},
+		{"not an ID", "1001\ntt0123\n", nil, true},
+		{"ID with comment attached", "1001#Show\n", nil, true},
+		{"zero", "0\n", nil, true},
+		{"negative", "-1001\n", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseIdList(strings.NewReader(tt.list))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseIdList() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.EqualFunc(got, tt.want, func(a, b AnimeList.Anime) bool {
+				return a.Anidbid == b.Anidbid && a.Tmdbtv == b.Tmdbtv && a.Name == b.Name
+			}) {
+				t.Errorf("parseIdList() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"anime-to-seerr-blocklist/pkg/blocklistsync"
+)
+
+const approvalBatchSize = 20
+
+// approveInteractively shows the pending series in batches and asks whether to add each batch to the blocklist
+func approveInteractively(in io.Reader, out io.Writer) func(ctx context.Context, pending []blocklistSync.Series) ([]blocklistSync.Series, bool) {
+	scanner := bufio.NewScanner(in)
+
+	return func(ctx context.Context, pending []blocklistSync.Series) ([]blocklistSync.Series, bool) {
+		approved := make([]blocklistSync.Series, 0, len(pending))
+
+		for start := 0; start < len(pending); start += approvalBatchSize {
+			batch := pending[start:min(start+approvalBatchSize, len(pending))]
+			fmt.Fprintf(out, "\nSeries %d-%d of %d to add to the blocklist:\n", start+1, start+len(batch), len(pending))
+			for _, series := range batch {
+				fmt.Fprintf(out, "  %s (%v)\n", series.Title, series.TmdbId)
+			}
+
+			for {
+				if ctx.Err() != nil {
+					return approved, true
+				}
+				fmt.Fprint(out, "Add these? [y]es, [n]o, [a]ll remaining, [q]uit: ")
+				if !scanner.Scan() {
+					// No more input, so nothing more can be approved
+					fmt.Fprintln(out)
+					return approved, true
+				}
+
+				switch strings.ToLower(strings.TrimSpace(scanner.Text())) {
+				case "y", "yes":
+					approved = append(approved, batch...)
+				case "n", "no":
+				case "a", "all":
+					return append(approved, pending[start:]...), false
+				case "q", "quit":
+					return approved, true
+				default:
+					continue
+				}
+				break
+			}
+		}
+
+		return approved, false
+	}
+}
@@ -0,0 +1,60 @@
+package anidbApi
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"anime-to-seerr-blocklist/internal/rest"
+)
+
+const apiUrl = "http://api.anidb.net:9001"
+
+// Client identifies itself with a client registered on AniDB, without which requests are refused
+type Client struct {
+	*restApi.Client
+	clientName, clientVersion string
+}
+
+func NewClient(clientName, clientVersion string) (*Client, error) {
+	anidbUrl, err := restApi.ParseHostUrl(apiUrl, "httpapi")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{restApi.NewClient(anidbUrl, http.Header{}), clientName, clientVersion}, nil
+}
+
+// GetAnime fetches the details of the AniDB entry anidbId
+func (c *Client) GetAnime(ctx context.Context, anidbId int) (*Anime, error) {
+	values := url.Values{
+		"request":   []string{"anime"},
+		"client":    []string{c.clientName},
+		"clientver": []string{c.clientVersion},
+		"protover":  []string{"1"},
+		"aid":       []string{strconv.Itoa(anidbId)},
+	}
+
+	// Errors come back with a 200 status in place of the anime
+	var body string
+	if err := c.Get(ctx, "", values, &body); err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(strings.TrimSpace(body), "<error") {
+		var apiErr Error
+		if err := xml.Unmarshal([]byte(body), &apiErr); err != nil {
+			return nil, fmt.Errorf("failed to decode error for AniDB %v: %w", anidbId, err)
+		}
+		return nil, &apiErr
+	}
+
+	var anime Anime
+	if err := xml.Unmarshal([]byte(body), &anime); err != nil {
+		return nil, fmt.Errorf("failed to decode AniDB %v: %w", anidbId, err)
+	}
+	return &anime, nil
+}
@@ -0,0 +1,27 @@
+package anidbApi
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Anime struct {
+	Id           int    `xml:"id,attr"`
+	Restricted   bool   `xml:"restricted,attr"`
+	Type         string `xml:"type"`
+	EpisodeCount int    `xml:"episodecount"`
+}
+
+type Error struct {
+	Code    int    `xml:"code,attr"`
+	Message string `xml:",chardata"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("AniDB error %d: %s", e.Code, strings.TrimSpace(e.Message))
+}
+
+// Banned reports whether the client has been banned for making too many requests, so that any more are pointless
+func (e *Error) Banned() bool {
+	return strings.EqualFold(strings.TrimSpace(e.Message), "banned")
+}
@@ -0,0 +1,46 @@
+package anilistApi
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"anime-to-seerr-blocklist/internal/rest"
+)
+
+var graphqlUrl = &url.URL{Scheme: "https", Host: "graphql.anilist.co"}
+
+type Client struct {
+	*restApi.Client
+}
+
+func NewClient() *Client {
+	return &Client{restApi.NewClient(graphqlUrl, http.Header{})}
+}
+
+// Query performs a GraphQL query, decoding its data into respData
+func (c *Client) Query(ctx context.Context, query string, variables map[string]any, respData any) error {
+	resp := struct {
+		Data   any `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}{Data: respData}
+
+	if err := c.Post(ctx, "", nil, map[string]any{"query": query, "variables": variables}, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) != 0 {
+		return &GraphQLError{Message: resp.Errors[0].Message}
+	}
+
+	return nil
+}
+
+type GraphQLError struct {
+	Message string
+}
+
+func (e *GraphQLError) Error() string {
+	return "AniList: " + e.Message
+}
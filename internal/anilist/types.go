@@ -0,0 +1,22 @@
+package anilistApi
+
+const MediaListCollectionQuery = `query ($userName: String, $status: [MediaListStatus]) {
+  MediaListCollection(userName: $userName, type: ANIME, status_in: $status) {
+    lists { entries { media { id title { romaji } } } }
+  }
+}`
+
+type MediaListCollectionData struct {
+	MediaListCollection struct {
+		Lists []struct {
+			Entries []struct {
+				Media struct {
+					Id    int `json:"id"`
+					Title struct {
+						Romaji string `json:"romaji"`
+					} `json:"title"`
+				} `json:"media"`
+			} `json:"entries"`
+		} `json:"lists"`
+	} `json:"MediaListCollection"`
+}
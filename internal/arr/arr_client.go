@@ -0,0 +1,21 @@
+// Package arrApi is a client for the v3 API shared by Sonarr and Radarr
+package arrApi
+
+import (
+	"net/http"
+
+	"anime-to-seerr-blocklist/internal/rest"
+)
+
+type Client struct {
+	*restApi.Client
+}
+
+func NewClient(hostUrl, apiKey, hardcodedEndpoint string) (*Client, error) {
+	arrHostUrl, err := restApi.ParseHostUrl(hostUrl, "api", "v3", "/", hardcodedEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{restApi.NewClient(arrHostUrl, http.Header{"X-Api-Key": []string{apiKey}})}, nil
+}
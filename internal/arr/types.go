@@ -0,0 +1,32 @@
+package arrApi
+
+// ImportListExclusion is Sonarr's ImportListExclusionResource
+type ImportListExclusion struct {
+	Id     int    `json:"id,omitzero"`
+	TvdbId int    `json:"tvdbId"`
+	Title  string `json:"title"`
+}
+
+// Exclusion is Radarr's ImportListExclusionResource
+type Exclusion struct {
+	Id         int    `json:"id,omitzero"`
+	TmdbId     int    `json:"tmdbId"`
+	MovieTitle string `json:"movieTitle"`
+	MovieYear  int    `json:"movieYear"`
+}
+
+// Defines values for Sonarr's SeriesType.
+const (
+	SeriesTypeStandard = "standard"
+	SeriesTypeDaily    = "daily"
+	SeriesTypeAnime    = "anime"
+)
+
+// Series is Sonarr's SeriesResource
+type Series struct {
+	Title      string `json:"title"`
+	TvdbId     int    `json:"tvdbId"`
+	TmdbId     int    `json:"tmdbId,omitzero"` // Sonarr v4
+	Monitored  bool   `json:"monitored"`
+	SeriesType string `json:"seriesType"`
+}
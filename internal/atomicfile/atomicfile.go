@@ -0,0 +1,46 @@
+package atomicFile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"codeberg.org/sdassow/atomic"
+)
+
+// WriteFile replaces filename with data such that it's never left partially written
+func WriteFile(filename string, data []byte) error {
+	dir, file := filepath.Split(filename)
+	if dir == "" {
+		dir = "."
+	}
+
+	f, err := os.CreateTemp(dir, file)
+	if err != nil {
+		return fmt.Errorf("cannot create temp file: %v", err)
+	}
+	fname := f.Name()
+	defer func() {
+		if err != nil {
+			_ = os.Remove(fname)
+		}
+	}()
+
+	if _, err = f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("cannot flush tempfile %q: %v", fname, err)
+	}
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("cannot close tempfile %q: %v", fname, err)
+	}
+
+	err = atomic.ReplaceFile(fname, filename)
+	if err != nil {
+		return fmt.Errorf("cannot replace %q with tempfile %q: %v", filename, fname, err)
+	}
+	return nil
+}
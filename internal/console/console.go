@@ -0,0 +1,74 @@
+package console
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	green  = "\x1b[32m"
+	yellow = "\x1b[33m"
+	red    = "\x1b[31m"
+	dim    = "\x1b[2m"
+	reset  = "\x1b[0m"
+)
+
+// Color enables coloring the output, which should only be done when it's a terminal
+var Color = false
+
+func printf(color, format string, a ...any) {
+	if Color {
+		format = color + format[:len(format)-1] + reset + "\n"
+	}
+	fmt.Printf(format, a...)
+}
+
+// Added prints a line about something added to a server, in green. format must end in a newline
+func Added(format string, a ...any) {
+	printf(green, format, a...)
+}
+
+// Removed prints a line about something removed from or declined on a server, in yellow. format must end in a newline
+func Removed(format string, a ...any) {
+	printf(yellow, format, a...)
+}
+
+// Skipped prints a line about something left alone, dimmed. format must end in a newline
+func Skipped(format string, a ...any) {
+	printf(dim, format, a...)
+}
+
+// IsTerminal reports whether f is a terminal rather than a file or pipe
+func IsTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+type errorWriter struct {
+	w io.Writer
+}
+
+// ErrorWriter returns a writer for the log package that colors lines about errors red
+func ErrorWriter(w io.Writer) io.Writer {
+	return errorWriter{w}
+}
+
+func (e errorWriter) Write(p []byte) (int, error) {
+	if !bytes.Contains(p, []byte("Error")) {
+		return e.w.Write(p)
+	}
+
+	line := make([]byte, 0, len(p)+len(red)+len(reset))
+	line = append(line, red...)
+	line = append(line, bytes.TrimSuffix(p, []byte("\n"))...)
+	line = append(line, reset...)
+	if bytes.HasSuffix(p, []byte("\n")) {
+		line = append(line, '\n')
+	}
+	if _, err := e.w.Write(line); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
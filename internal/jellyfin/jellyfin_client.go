@@ -0,0 +1,20 @@
+package jellyfinApi
+
+import (
+	"net/http"
+
+	"anime-to-seerr-blocklist/internal/rest"
+)
+
+type Client struct {
+	*restApi.Client
+}
+
+func NewClient(hostUrl, apiKey, hardcodedEndpoint string) (*Client, error) {
+	jellyfinHostUrl, err := restApi.ParseHostUrl(hostUrl, "/", hardcodedEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{restApi.NewClient(jellyfinHostUrl, http.Header{"X-Emby-Token": []string{apiKey}})}, nil
+}
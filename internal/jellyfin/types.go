@@ -0,0 +1,11 @@
+package jellyfinApi
+
+type BaseItem struct {
+	Name        string            `json:"Name"`
+	ProviderIds map[string]string `json:"ProviderIds"`
+}
+
+type GetItemsResponse struct {
+	Items            []BaseItem `json:"Items"`
+	TotalRecordCount int        `json:"TotalRecordCount"`
+}
@@ -0,0 +1,22 @@
+package malApi
+
+import (
+	"net/http"
+
+	"anime-to-seerr-blocklist/internal/rest"
+)
+
+const apiUrl = "https://api.myanimelist.net"
+
+type Client struct {
+	*restApi.Client
+}
+
+func NewClient(clientId, hardcodedEndpoint string) (*Client, error) {
+	malUrl, err := restApi.ParseHostUrl(apiUrl, "v2", "/", hardcodedEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{restApi.NewClient(malUrl, http.Header{"X-MAL-CLIENT-ID": []string{clientId}})}, nil
+}
@@ -0,0 +1,19 @@
+package malApi
+
+// Defines values for the status of an anime list entry.
+const (
+	StatusWatching    = "watching"
+	StatusPlanToWatch = "plan_to_watch"
+)
+
+type GetUserAnimeListResponse struct {
+	Data []struct {
+		Node struct {
+			Id    int    `json:"id"`
+			Title string `json:"title"`
+		} `json:"node"`
+	} `json:"data"`
+	Paging struct {
+		Next string `json:"next"`
+	} `json:"paging"`
+}
@@ -0,0 +1,36 @@
+package mdblistApi
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"net/url"
+
+	"anime-to-seerr-blocklist/internal/rest"
+)
+
+const apiUrl = "https://api.mdblist.com"
+
+// Client passes the API key as a query parameter, which is the only way MDBList accepts it
+type Client struct {
+	*restApi.Client
+	apiKey string
+}
+
+func NewClient(apiKey, hardcodedEndpoint string) (*Client, error) {
+	mdblistUrl, err := restApi.ParseHostUrl(apiUrl, "/", hardcodedEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{restApi.NewClient(mdblistUrl, http.Header{}), apiKey}, nil
+}
+
+func (c *Client) Get(ctx context.Context, endpoint string, queryParams url.Values, respBody any) error {
+	values := maps.Clone(queryParams)
+	if values == nil {
+		values = url.Values{}
+	}
+	values.Set("apikey", c.apiKey)
+	return c.Client.Get(ctx, endpoint, values, respBody)
+}
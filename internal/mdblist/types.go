@@ -0,0 +1,13 @@
+package mdblistApi
+
+type ListItem struct {
+	// Id is the item's TMDB ID
+	Id        int    `json:"id"`
+	Title     string `json:"title"`
+	MediaType string `json:"mediatype"`
+}
+
+type GetListItemsResponse struct {
+	Movies []ListItem `json:"movies"`
+	Shows  []ListItem `json:"shows"`
+}
@@ -0,0 +1,20 @@
+package ombiApi
+
+import (
+	"net/http"
+
+	"anime-to-seerr-blocklist/internal/rest"
+)
+
+type Client struct {
+	*restApi.Client
+}
+
+func NewClient(hostUrl, apiKey, hardcodedEndpoint string) (*Client, error) {
+	ombiHostUrl, err := restApi.ParseHostUrl(hostUrl, "api", "v1", "/", hardcodedEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{restApi.NewClient(ombiHostUrl, http.Header{"ApiKey": []string{apiKey}})}, nil
+}
@@ -0,0 +1,30 @@
+package ombiApi
+
+// TheMovieDbSettings is the body of Settings/themoviedb
+type TheMovieDbSettings struct {
+	ShowAdultMovies       bool  `json:"showAdultMovies"`
+	ExcludedKeywordIds    []int `json:"excludedKeywordIds"`
+	ExcludedMovieGenreIds []int `json:"excludedMovieGenreIds"`
+	ExcludedTvGenreIds    []int `json:"excludedTvGenreIds"`
+}
+
+type ChildRequest struct {
+	Id        int  `json:"id"`
+	Approved  bool `json:"approved"`
+	Available bool `json:"available"`
+	Denied    bool `json:"denied"`
+}
+
+type TvRequest struct {
+	Id                 int            `json:"id"`
+	Title              string         `json:"title"`
+	TvDbId             int            `json:"tvDbId"`
+	ExternalProviderId int            `json:"externalProviderId"` // TMDB
+	ChildRequests      []ChildRequest `json:"childRequests"`
+}
+
+// DenyTvModel is the body of Request/tv/deny
+type DenyTvModel struct {
+	Id     int    `json:"id"`
+	Reason string `json:"reason,omitzero"`
+}
@@ -0,0 +1,22 @@
+package plexApi
+
+import (
+	"net/http"
+
+	"anime-to-seerr-blocklist/internal/rest"
+)
+
+const discoverUrl = "https://discover.provider.plex.tv"
+
+type Client struct {
+	*restApi.Client
+}
+
+func NewClient(token, hardcodedEndpoint string) (*Client, error) {
+	plexUrl, err := restApi.ParseHostUrl(discoverUrl, "library", "/", hardcodedEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{restApi.NewClient(plexUrl, http.Header{"X-Plex-Token": []string{token}})}, nil
+}
@@ -0,0 +1,19 @@
+package plexApi
+
+type Guid struct {
+	Id string `json:"id"`
+}
+
+type Metadata struct {
+	RatingKey string `json:"ratingKey"`
+	Title     string `json:"title"`
+	Type      string `json:"type"`
+	Guid      []Guid `json:"Guid"`
+}
+
+type MediaContainerResponse struct {
+	MediaContainer struct {
+		TotalSize int        `json:"totalSize"`
+		Metadata  []Metadata `json:"Metadata"`
+	} `json:"MediaContainer"`
+}
@@ -0,0 +1,70 @@
+package restApi
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+)
+
+var (
+	// ipNetwork replaces "tcp" to restrict connections to IPv4 or IPv6
+	ipNetwork = "tcp"
+	// hostAddrs maps hosts to the addresses to connect to instead of resolving them
+	hostAddrs = map[string]string{}
+)
+
+// SetIPVersion restricts every client to connecting over IPv4 or IPv6, or lifts the restriction given 0
+func SetIPVersion(version int) error {
+	switch version {
+	case 0:
+		ipNetwork = "tcp"
+	case 4, 6:
+		ipNetwork = fmt.Sprintf("tcp%d", version)
+	default:
+		return fmt.Errorf("unknown IP version %d", version)
+	}
+	return nil
+}
+
+// SetDNSServer makes every client resolve hosts using the DNS server at addr instead of the system's resolver
+func SetDNSServer(addr string) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "53")
+	}
+
+	var d net.Dialer
+	dialer.Resolver = &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return d.DialContext(ctx, network, addr)
+		},
+	}
+}
+
+// AddHostMapping makes every client connect to ip for host instead of resolving it
+func AddHostMapping(host, ip string) error {
+	if net.ParseIP(ip) == nil {
+		return fmt.Errorf("%q isn't an IP address", ip)
+	}
+	hostAddrs[strings.ToLower(host)] = ip
+	return nil
+}
+
+func dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	if socket, ok := unixSocket(host); ok {
+		return dialer.DialContext(ctx, "unix", socket)
+	}
+	if ip, ok := hostAddrs[strings.ToLower(host)]; ok {
+		addr = net.JoinHostPort(ip, port)
+	}
+	if network == "tcp" {
+		network = ipNetwork
+	}
+	return dialer.DialContext(ctx, network, addr)
+}
@@ -0,0 +1,33 @@
+package restApi
+
+import (
+	"fmt"
+	"io"
+)
+
+// MaxResponseSize bounds how much of a response body is read, so a misbehaving server or proxy can't exhaust memory
+const MaxResponseSize = 128 << 20
+
+type limitedReader struct {
+	r     io.Reader
+	limit int64
+	read  int64
+}
+
+// LimitReader returns a reader that reads from r but fails once more than n bytes have been read, unlike
+// io.LimitReader which would silently truncate the response
+func LimitReader(r io.Reader, n int64) io.Reader {
+	return &limitedReader{r: r, limit: n}
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if remaining := l.limit - l.read + 1; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.limit {
+		return n, fmt.Errorf("response exceeds %d bytes", l.limit)
+	}
+	return n, err
+}
@@ -0,0 +1,287 @@
+package restApi
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+type HTTPError struct {
+	StatusCode int
+	Status     string
+	Method     string
+	URL        string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("failed to %s %s: %s", e.Method, e.URL, e.Status)
+}
+
+type Client struct {
+	httpClient *http.Client
+	baseUrlUrl *url.URL
+	baseUrl    string
+	header     http.Header
+}
+
+// Timeouts are the clients' connection settings, of which zero disables any but KeepAlive
+type Timeouts struct {
+	Dial           time.Duration
+	KeepAlive      time.Duration
+	ResponseHeader time.Duration
+	IdleConn       time.Duration
+	// Also bounds reading the response body, which ResponseHeader doesn't
+	Request time.Duration
+}
+
+var DefaultTimeouts = Timeouts{
+	Dial:           30 * time.Second,
+	KeepAlive:      time.Minute,
+	ResponseHeader: 10 * time.Second,
+	IdleConn:       http.DefaultTransport.(*http.Transport).IdleConnTimeout,
+	Request:        5 * time.Minute,
+}
+
+var (
+	dialer = &net.Dialer{Timeout: DefaultTimeouts.Dial, KeepAlive: DefaultTimeouts.KeepAlive}
+	proxy  = http.ProxyFromEnvironment
+)
+
+// UserAgent is sent with every request that doesn't set its own
+var UserAgent = "anime-to-seerr-blocklist"
+
+var defaultHttpClient = &http.Client{
+	Timeout: DefaultTimeouts.Request,
+	Transport: &http.Transport{
+		Proxy: func(req *http.Request) (*url.URL, error) {
+			if _, ok := unixSocket(req.URL.Hostname()); ok {
+				return nil, nil
+			}
+			return proxy(req)
+		},
+		MaxIdleConns:          http.DefaultTransport.(*http.Transport).MaxIdleConns,
+		IdleConnTimeout:       DefaultTimeouts.IdleConn,
+		TLSHandshakeTimeout:   http.DefaultTransport.(*http.Transport).TLSHandshakeTimeout,
+		ExpectContinueTimeout: http.DefaultTransport.(*http.Transport).ExpectContinueTimeout,
+		ResponseHeaderTimeout: DefaultTimeouts.ResponseHeader,
+		DialContext:           dialContext,
+		ForceAttemptHTTP2:     false,
+	},
+}
+
+// SetTimeouts changes every client's timeouts from DefaultTimeouts
+func SetTimeouts(t Timeouts) {
+	dialer.Timeout = t.Dial
+	dialer.KeepAlive = t.KeepAlive
+	transport := defaultHttpClient.Transport.(*http.Transport)
+	transport.ResponseHeaderTimeout = t.ResponseHeader
+	transport.IdleConnTimeout = t.IdleConn
+	defaultHttpClient.Timeout = t.Request
+}
+
+// SetHTTP2 makes every client use HTTP/2 with servers that support it
+func SetHTTP2(enabled bool) {
+	defaultHttpClient.Transport.(*http.Transport).ForceAttemptHTTP2 = enabled
+}
+
+// SetMaxIdleConnsPerHost changes how many idle connections to each server are kept for reuse
+func SetMaxIdleConnsPerHost(n int) {
+	defaultHttpClient.Transport.(*http.Transport).MaxIdleConnsPerHost = n
+}
+
+// SetProxy routes every client's requests through proxyUrl, which may be an http, https or socks5 URL, instead of the
+// proxy given by $HTTP_PROXY etc.
+func SetProxy(proxyUrl *url.URL) {
+	proxy = http.ProxyURL(proxyUrl)
+}
+
+// SetTLSConfig makes every client connect using config, e.g. to trust a private CA or present a client certificate
+func SetTLSConfig(config *tls.Config) {
+	defaultHttpClient.Transport.(*http.Transport).TLSClientConfig = config
+}
+
+// ParseHostUrl parses a user-supplied server URL, which may include the base path the server is hosted under, and
+// appends the API's path to it. The elements of apiPath before a "/" element are the API's base path, which is
+// tolerated at the end of hostUrl too
+func ParseHostUrl(hostUrl string, apiPath ...string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(hostUrl))
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "unix" {
+		if u, err = unixSocketUrl(u); err != nil {
+			return nil, err
+		}
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, errors.New("missing scheme/host")
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return nil, errors.New("unexpected query/fragment")
+	}
+
+	if i := slices.Index(apiPath, "/"); i > 0 {
+		basePath := "/" + path.Join(apiPath[:i]...)
+		if p := strings.TrimRight(u.Path, "/"); strings.HasSuffix(p, basePath) {
+			u.Path = strings.TrimSuffix(p, basePath)
+			u.RawPath = ""
+		}
+	}
+
+	return u.JoinPath(apiPath...), nil
+}
+
+// NewClient returns a client for the JSON API at baseUrl, sending header with every request
+func NewClient(baseUrl *url.URL, header http.Header) *Client {
+	return &Client{
+		baseUrlUrl: baseUrl,
+		baseUrl:    baseUrl.String(),
+		header:     header,
+		httpClient: defaultHttpClient,
+	}
+}
+
+// URL returns the base URL of c's requests
+func (c *Client) URL() *url.URL {
+	u := *c.baseUrlUrl
+	return &u
+}
+
+// WithHTTPClient returns a copy of c that makes its requests with httpClient, e.g. one with a fake transport for
+// testing
+func (c *Client) WithHTTPClient(httpClient *http.Client) *Client {
+	c2 := *c
+	c2.httpClient = httpClient
+	return &c2
+}
+
+// WithPath returns a copy of c for the part of the API below path
+func (c *Client) WithPath(path string) *Client {
+	c2 := *c
+	c2.baseUrlUrl = c.baseUrlUrl.JoinPath(path)
+	c2.baseUrl = c2.baseUrlUrl.String()
+	return &c2
+}
+
+// WithHeader returns a copy of c that additionally sends the given header
+func (c *Client) WithHeader(key, value string) *Client {
+	c2 := *c
+	c2.header = c.header.Clone()
+	c2.header.Set(key, value)
+	return &c2
+}
+
+func (c *Client) do(ctx context.Context, method string, endpoint string, queryParams url.Values, reqBody any, respBody any) error {
+	var finalUrl string
+	if queryParams == nil {
+		if endpoint == "" {
+			finalUrl = c.baseUrl
+		} else {
+			finalUrl = c.baseUrl + endpoint
+		}
+	} else {
+		var u *url.URL
+		if endpoint == "" {
+			u2 := *c.baseUrlUrl
+			u = &u2
+		} else {
+			u = c.baseUrlUrl.JoinPath(endpoint)
+		}
+		u.RawQuery = queryParams.Encode()
+
+		finalUrl = u.String()
+	}
+
+	var pReqBody io.Reader = nil
+	var jsonBuf bytes.Buffer
+	if reqBody != nil {
+		jsonEnc := json.NewEncoder(&jsonBuf)
+		jsonEnc.SetEscapeHTML(false)
+		if err := jsonEnc.Encode(reqBody); err != nil {
+			return fmt.Errorf("failed to serialise request body to JSON for %s: %w", finalUrl, err)
+		}
+		pReqBody = &jsonBuf
+	}
+
+	if method != http.MethodGet {
+		// Let changes complete when interrupted, they're bounded by the transport's timeouts regardless
+		ctx = context.WithoutCancel(ctx)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, finalUrl, pReqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create %s request for %s: %w", method, finalUrl, err)
+	}
+	req.Header.Set("Connection", "keep-alive")
+	req.Header.Set("User-Agent", UserAgent)
+	if reqBody != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if respBody != nil {
+		req.Header.Set("Accept", "application/json")
+	}
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= 300 {
+		return &HTTPError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Method:     method,
+			URL:        finalUrl,
+		}
+	}
+
+	if respBody != nil {
+		body := LimitReader(resp.Body, MaxResponseSize)
+		if ptr, ok := respBody.(*string); !ok {
+			err = json.NewDecoder(body).Decode(respBody)
+		} else {
+			var all []byte
+			all, err = io.ReadAll(body)
+			if err == nil {
+				*ptr = string(all)
+			}
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to decode JSON response from %s: %w", finalUrl, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) Delete(ctx context.Context, endpoint string, queryParams url.Values, reqBody any) error {
+	return c.do(ctx, http.MethodDelete, endpoint, queryParams, reqBody, nil)
+}
+
+func (c *Client) Get(ctx context.Context, endpoint string, queryParams url.Values, respBody any) error {
+	return c.do(ctx, http.MethodGet, endpoint, queryParams, nil, respBody)
+}
+
+func (c *Client) Put(ctx context.Context, endpoint string, queryParams url.Values, reqBody any, respBody any) error {
+	return c.do(ctx, http.MethodPut, endpoint, queryParams, reqBody, respBody)
+}
+
+func (c *Client) Post(ctx context.Context, endpoint string, queryParams url.Values, reqBody any, respBody any) error {
+	return c.do(ctx, http.MethodPost, endpoint, queryParams, reqBody, respBody)
+}
@@ -0,0 +1,49 @@
+package restApi
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"sync"
+)
+
+// Unix domain sockets are addressed by placeholder hosts that dialContext maps back to the sockets' paths
+var (
+	unixSocketsMu sync.Mutex
+	unixSockets   = map[string]string{}
+)
+
+const unixSocketHostSuffix = ".unix.invalid"
+
+// unixSocketUrl returns the HTTP URL standing in for a unix:///path/to/socket URL
+func unixSocketUrl(u *url.URL) (*url.URL, error) {
+	if u.Path == "" || u.Host != "" {
+		return nil, errors.New("expected unix:///path/to/socket")
+	}
+
+	unixSocketsMu.Lock()
+	defer unixSocketsMu.Unlock()
+
+	host := ""
+	for h, socket := range unixSockets {
+		if socket == u.Path {
+			host = h
+			break
+		}
+	}
+	if host == "" {
+		host = "socket" + strconv.Itoa(len(unixSockets)) + unixSocketHostSuffix
+		unixSockets[host] = u.Path
+	}
+
+	return &url.URL{Scheme: "http", Host: host}, nil
+}
+
+// unixSocket returns the path of the socket that host stands in for, if any
+func unixSocket(host string) (string, bool) {
+	unixSocketsMu.Lock()
+	defer unixSocketsMu.Unlock()
+
+	socket, ok := unixSockets[host]
+	return socket, ok
+}
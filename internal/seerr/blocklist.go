@@ -0,0 +1,7 @@
+package seerrApi
+
+// PostBlocklistBulkJSONRequestBody adds several entries to the blocklist at once, for servers with a bulk endpoint,
+// which Seerr's own API doesn't describe. The entries are added all together or not at all
+type PostBlocklistBulkJSONRequestBody struct {
+	Items []PostBlocklistJSONRequestBody `json:"items"`
+}
@@ -0,0 +1,65 @@
+package seerrApi
+
+import (
+	"context"
+	"maps"
+	"math"
+	"net/url"
+	"strconv"
+)
+
+// MaxTake is as many results as Seerr returns per page, as it otherwise defaults to 25
+const MaxTake = math.MaxInt16
+
+// PageSize returns how many results the pages of a list hold, given the first page asked for take and returned
+// results of them. Servers may cap take, whether saying so in pageInfo or not, and skipping by take then would miss
+// the results in between
+func PageSize(take int, pageInfo PageInfo, results int) int {
+	if pageInfo.PageSize > 0 && pageInfo.PageSize < take {
+		take = pageInfo.PageSize
+	}
+	if results < take && results < pageInfo.Results {
+		take = results
+	}
+	return take
+}
+
+// Getter is the part of a client that GetPages needs
+type Getter interface {
+	Get(ctx context.Context, endpoint string, queryParams url.Values, respBody any) error
+}
+
+// GetPages fetches every page of the list at client's base path, filtered by values, passing the results of each to
+// add in turn
+func GetPages[T any](ctx context.Context, client Getter, values url.Values, add func(results []T)) error {
+	values = maps.Clone(values)
+	if values == nil {
+		values = url.Values{}
+	}
+
+	take := MaxTake
+	for skip := 0; ; {
+		values.Set("take", strconv.Itoa(take))
+		values.Set("skip", strconv.Itoa(skip))
+
+		var resp struct {
+			PageInfo PageInfo `json:"pageInfo"`
+			Results  []T      `json:"results"`
+		}
+		if err := client.Get(ctx, "", values, &resp); err != nil {
+			return err
+		}
+		add(resp.Results)
+
+		if len(resp.Results) == 0 {
+			return nil
+		}
+		if skip == 0 {
+			take = PageSize(take, resp.PageInfo, len(resp.Results))
+		}
+		skip += take
+		if skip >= resp.PageInfo.Results {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package seerrApi
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// fakeList serves total results, returning at most limit per page if set, and saying so in pageInfo if reportLimit
+type fakeList struct {
+	total, limit int
+	reportLimit  bool
+}
+
+func (f *fakeList) Get(_ context.Context, _ string, values url.Values, respBody any) error {
+	take, _ := strconv.Atoi(values.Get("take"))
+	skip, _ := strconv.Atoi(values.Get("skip"))
+	if f.limit > 0 {
+		take = min(take, f.limit)
+	}
+
+	resp := struct {
+		PageInfo PageInfo `json:"pageInfo"`
+		Results  []int    `json:"results"`
+	}{PageInfo: PageInfo{Results: f.total}}
+	if f.reportLimit {
+		resp.PageInfo.PageSize = take
+	}
+	for i := skip; i < min(skip+take, f.total); i++ {
+		resp.Results = append(resp.Results, i)
+	}
+
+	// Through JSON, as the real client would
+	b, err := json.Marshal(&resp)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, respBody)
+}
+
+func TestGetPages(t *testing.T) {
+	tests := []struct {
+		name string
+		list fakeList
+	}{
+		{"empty", fakeList{}},
+		{"one page", fakeList{total: 10}},
+		{"capped take reported", fakeList{total: 10, limit: 3, reportLimit: true}},
+		{"capped take unreported", fakeList{total: 10, limit: 3}},
+		{"capped take with exact pages", fakeList{total: 9, limit: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			err := GetPages(t.Context(), &tt.list, url.Values{"filter": []string{"all"}}, func(results []int) {
+				got = append(got, results...)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := make([]int, tt.list.total)
+			for i := range want {
+				want[i] = i
+			}
+			if !slices.Equal(got, want) && (len(got) != 0 || len(want) != 0) {
+				t.Errorf("results = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestPageSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		take     int
+		pageInfo PageInfo
+		results  int
+		want     int
+	}{
+		{"uncapped", 100, PageInfo{Results: 10}, 10, 100},
+		{"capped in pageInfo", 100, PageInfo{PageSize: 25, Results: 50}, 25, 25},
+		{"capped silently", 100, PageInfo{Results: 50}, 20, 20},
+		{"last page short", 10, PageInfo{PageSize: 10, Results: 5}, 5, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PageSize(tt.take, tt.pageInfo, tt.results); got != tt.want {
+				t.Errorf("PageSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -23,29 +23,32 @@ type PageInfo struct {
 // User defines model for User.
 type User struct {
 	/*Avatar            *string  `json:"avatar,omitempty"`
-	CreatedAt         *string  `json:"createdAt,omitempty"`
-	Email             *string  `json:"email,omitempty"`*/
-	Id int `json:"id,omitempty"`
+	CreatedAt         *string  `json:"createdAt,omitempty"`*/
+	Email string `json:"email,omitzero"`
+	Id    int    `json:"id,omitempty"`
 	/*JellyfinAuthToken *string  `json:"jellyfinAuthToken,omitempty"`
 	Permissions       *float32 `json:"permissions,omitempty"`
 	PlexToken         *string  `json:"plexToken,omitempty"`
 	PlexUsername      *string  `json:"plexUsername,omitempty"`
 	RequestCount      *float32 `json:"requestCount,omitempty"`
 	UpdatedAt         *string  `json:"updatedAt,omitempty"`
-	UserType          *int     `json:"userType,omitempty"`
-	Username          *string  `json:"username,omitempty"`*/
+	UserType          *int     `json:"userType,omitempty"`*/
+	Username string `json:"username,omitzero"`
+}
+
+// BlocklistEntry defines model for an entry of the blocklist.
+type BlocklistEntry struct {
+	//CreatedAt *string  `json:"createdAt,omitempty"`
+	//Id        *float32 `json:"id,omitempty"`
+	MediaType MediaType `json:"mediaType,omitzero"`
+	Title     string    `json:"title,omitzero"`
+	TmdbId    int       `json:"tmdbId,omitzero"`
+	User      *User     `json:"user,omitzero"`
 }
 
 type GetBlocklistResponse struct {
-	PageInfo PageInfo `json:"pageInfo,omitempty"`
-	Results  []struct {
-		//CreatedAt *string  `json:"createdAt,omitempty"`
-		//Id        *float32 `json:"id,omitempty"`
-		MediaType MediaType `json:"mediaType,omitzero"`
-		//Title     *string  `json:"title,omitempty"`
-		TmdbId int `json:"tmdbId,omitzero"`
-		//User   *User `json:"user,omitzero"`
-	} `json:"results,omitzero"`
+	PageInfo PageInfo         `json:"pageInfo,omitempty"`
+	Results  []BlocklistEntry `json:"results,omitzero"`
 }
 
 // Defines values for GetBlocklistParamsFilter.
@@ -62,3 +65,120 @@ type PostBlocklistJSONRequestBody struct {
 	Title     string    `json:"title,omitzero"`
 	User      int       `json:"user,omitempty"`
 }
+
+type GetUserResponse struct {
+	PageInfo PageInfo `json:"pageInfo,omitempty"`
+	Results  []User   `json:"results,omitzero"`
+}
+
+type GetUserWatchlistResponse struct {
+	Page         int `json:"page,omitempty"`
+	TotalPages   int `json:"totalPages,omitempty"`
+	TotalResults int `json:"totalResults,omitempty"`
+	Results      []struct {
+		MediaType MediaType `json:"mediaType,omitzero"`
+		RatingKey string    `json:"ratingKey,omitzero"`
+		Title     string    `json:"title,omitzero"`
+		TmdbId    int       `json:"tmdbId,omitzero"`
+	} `json:"results,omitzero"`
+}
+
+// MainSettings defines model for MainSettings.
+type MainSettings struct {
+	BlocklistedTags      string `json:"blocklistedTags,omitzero"`
+	BlocklistedTagsLimit int    `json:"blocklistedTagsLimit,omitzero"`
+}
+
+// OverrideRule defines model for OverrideRule.
+type OverrideRule struct {
+	Id              int    `json:"id,omitzero"`
+	Users           string `json:"users,omitzero"`
+	Genre           string `json:"genre,omitzero"`
+	Language        string `json:"language,omitzero"`
+	Keywords        string `json:"keywords,omitzero"`
+	ProfileId       int    `json:"profileId,omitzero"`
+	RootFolder      string `json:"rootFolder,omitzero"`
+	Tags            string `json:"tags,omitzero"`
+	RadarrServiceId *int   `json:"radarrServiceId,omitempty"`
+	SonarrServiceId *int   `json:"sonarrServiceId,omitempty"`
+}
+
+// Defines values for Permission.
+const (
+	PermissionAdmin          = 2
+	PermissionRequest        = 32
+	PermissionRequest4k      = 1024
+	PermissionRequest4kMovie = 2048
+	PermissionRequest4kTv    = 4096
+	PermissionRequestMovie   = 262144
+	PermissionRequestTv      = 524288
+)
+
+// UserPermissionsSettings defines model for UserPermissionsSettings.
+type UserPermissionsSettings struct {
+	Permissions int `json:"permissions"`
+}
+
+// Defines values for MediaRequestStatus.
+const (
+	MediaRequestStatusPending  = 1
+	MediaRequestStatusApproved = 2
+	MediaRequestStatusDeclined = 3
+)
+
+// MediaRequest defines model for MediaRequest.
+type MediaRequest struct {
+	Id     int `json:"id,omitzero"`
+	Status int `json:"status,omitzero"`
+	Media  struct {
+		MediaType MediaType `json:"mediaType,omitzero"`
+		TmdbId    int       `json:"tmdbId,omitzero"`
+	} `json:"media,omitzero"`
+}
+
+type GetRequestResponse struct {
+	PageInfo PageInfo       `json:"pageInfo,omitempty"`
+	Results  []MediaRequest `json:"results,omitzero"`
+}
+
+// Defines values for GetRequestParamsFilter.
+const (
+	GetRequestParamsFilterPending string = "pending"
+)
+
+// Defines values for MediaInfoStatus.
+const (
+	MediaInfoStatusAvailable = 5
+)
+
+// MediaInfo defines model for MediaInfo.
+type MediaInfo struct {
+	MediaType MediaType `json:"mediaType,omitzero"`
+	Status    int       `json:"status,omitzero"`
+	TmdbId    int       `json:"tmdbId,omitzero"`
+}
+
+type GetMediaResponse struct {
+	PageInfo PageInfo    `json:"pageInfo,omitempty"`
+	Results  []MediaInfo `json:"results,omitzero"`
+}
+
+// Defines values for GetMediaParamsFilter.
+const (
+	GetMediaParamsFilterAvailable string = "available"
+)
+
+// GetBlocklistTmdbIdResponse defines model for the blocklist entry of a TMDB ID.
+type GetBlocklistTmdbIdResponse struct {
+	MediaType MediaType `json:"mediaType,omitzero"`
+	Title     string    `json:"title,omitzero"`
+	TmdbId    int       `json:"tmdbId,omitzero"`
+}
+
+// GetStatusResponse defines model for the server's status.
+type GetStatusResponse struct {
+	Version         string `json:"version,omitzero"`
+	CommitTag       string `json:"commitTag,omitzero"`
+	UpdateAvailable bool   `json:"updateAvailable,omitempty"`
+	CommitsBehind   int    `json:"commitsBehind,omitempty"`
+}
@@ -0,0 +1,20 @@
+package shokoApi
+
+import (
+	"net/http"
+
+	"anime-to-seerr-blocklist/internal/rest"
+)
+
+type Client struct {
+	*restApi.Client
+}
+
+func NewClient(hostUrl, apiKey, hardcodedEndpoint string) (*Client, error) {
+	shokoHostUrl, err := restApi.ParseHostUrl(hostUrl, "api", "v3", "/", hardcodedEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{restApi.NewClient(shokoHostUrl, http.Header{"apikey": []string{apiKey}})}, nil
+}
@@ -0,0 +1,17 @@
+package shokoApi
+
+type Series struct {
+	Name string `json:"Name"`
+	IDs  struct {
+		AniDB int   `json:"AniDB"`
+		TvDB  []int `json:"TvDB"`
+		TMDB  struct {
+			Show []int `json:"Show"`
+		} `json:"TMDB"`
+	} `json:"IDs"`
+}
+
+type GetSeriesResponse struct {
+	Total int      `json:"Total"`
+	List  []Series `json:"List"`
+}
@@ -0,0 +1,29 @@
+package simklApi
+
+import (
+	"net/http"
+
+	"anime-to-seerr-blocklist/internal/rest"
+)
+
+const apiUrl = "https://api.simkl.com"
+
+type Client struct {
+	*restApi.Client
+}
+
+// NewClient returns a client authenticated as the user that accessToken belongs to, or an anonymous client if it's
+// empty
+func NewClient(clientId, accessToken, hardcodedEndpoint string) (*Client, error) {
+	simklUrl, err := restApi.ParseHostUrl(apiUrl, "/", hardcodedEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{"simkl-api-key": []string{clientId}}
+	if accessToken != "" {
+		header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	return &Client{restApi.NewClient(simklUrl, header)}, nil
+}
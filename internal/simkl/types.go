@@ -0,0 +1,62 @@
+package simklApi
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// Defines values for the status of a list entry.
+const (
+	StatusWatching    = "watching"
+	StatusPlanToWatch = "plantowatch"
+)
+
+const PinResultOK = "OK"
+
+// Id decodes an ID that Simkl sends as either a string or a number
+type Id int
+
+func (id *Id) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			// Not an ID Simkl knows
+			return nil
+		}
+		*id = Id(n)
+		return nil
+	}
+	if string(b) == "null" {
+		return nil
+	}
+	return json.Unmarshal(b, (*int)(id))
+}
+
+type Pin struct {
+	Result          string `json:"result"`
+	UserCode        string `json:"user_code"`
+	VerificationUrl string `json:"verification_url"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+type PinStatus struct {
+	Result      string `json:"result"`
+	AccessToken string `json:"access_token"`
+}
+
+type AllItemsResponse struct {
+	Anime []struct {
+		Show struct {
+			Title string `json:"title"`
+			Ids   struct {
+				Anidb Id `json:"anidb"`
+				Tmdb  Id `json:"tmdb"`
+			} `json:"ids"`
+		} `json:"show"`
+	} `json:"anime"`
+}
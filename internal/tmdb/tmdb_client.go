@@ -0,0 +1,23 @@
+package tmdbApi
+
+import (
+	"net/http"
+
+	"anime-to-seerr-blocklist/internal/rest"
+)
+
+const apiUrl = "https://api.themoviedb.org"
+
+type Client struct {
+	*restApi.Client
+}
+
+// NewClient returns a client authenticated with an API read access token
+func NewClient(accessToken, hardcodedEndpoint string) (*Client, error) {
+	tmdbUrl, err := restApi.ParseHostUrl(apiUrl, "3", "/", hardcodedEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{restApi.NewClient(tmdbUrl, http.Header{"Authorization": []string{"Bearer " + accessToken}})}, nil
+}
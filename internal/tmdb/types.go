@@ -0,0 +1,37 @@
+package tmdbApi
+
+type TvResult struct {
+	Id               int      `json:"id"`
+	Name             string   `json:"name"`
+	OriginalLanguage string   `json:"original_language"`
+	OriginCountry    []string `json:"origin_country"`
+	GenreIds         []int    `json:"genre_ids"`
+	Popularity       float64  `json:"popularity"`
+	VoteCount        int      `json:"vote_count"`
+}
+
+type DiscoverTvResponse struct {
+	Page         int        `json:"page"`
+	TotalPages   int        `json:"total_pages"`
+	TotalResults int        `json:"total_results"`
+	Results      []TvResult `json:"results"`
+}
+
+// TMDB won't return pages past this
+const MaxPage = 500
+
+type TvDetails struct {
+	Id         int     `json:"id"`
+	Name       string  `json:"name"`
+	Popularity float64 `json:"popularity"`
+	VoteCount  int     `json:"vote_count"`
+	Seasons    []struct {
+		SeasonNumber int `json:"season_number"`
+	} `json:"seasons"`
+}
+
+type MovieDetails struct {
+	Id          int    `json:"id"`
+	Title       string `json:"title"`
+	ReleaseDate string `json:"release_date"`
+}
@@ -0,0 +1,34 @@
+package traktApi
+
+import (
+	"net/http"
+
+	"anime-to-seerr-blocklist/internal/rest"
+)
+
+const apiUrl = "https://api.trakt.tv"
+
+type Client struct {
+	*restApi.Client
+}
+
+// NewClient returns a client authenticated as the user that accessToken belongs to, or an anonymous client if it's
+// empty
+func NewClient(clientId, accessToken, hardcodedEndpoint string) (*Client, error) {
+	traktUrl, err := restApi.ParseHostUrl(apiUrl, "/", hardcodedEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{
+		"Trakt-Api-Version": []string{"2"},
+		"Trakt-Api-Key":     []string{clientId},
+	}
+	if accessToken != "" {
+		header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	return &Client{restApi.NewClient(traktUrl, header)}, nil
+}
+
+type HTTPError = restApi.HTTPError
@@ -0,0 +1,37 @@
+package tvdbApi
+
+import (
+	"context"
+	"net/http"
+
+	"anime-to-seerr-blocklist/internal/rest"
+)
+
+const apiUrl = "https://api4.thetvdb.com"
+
+type Client struct {
+	*restApi.Client
+}
+
+// Login returns a token for apiKey, and pin if the key is a user-supported one, to pass to NewClient
+func Login(ctx context.Context, apiKey, pin string) (string, error) {
+	loginUrl, err := restApi.ParseHostUrl(apiUrl, "v4", "/", "login")
+	if err != nil {
+		return "", err
+	}
+
+	var resp LoginResponse
+	if err = restApi.NewClient(loginUrl, http.Header{}).Post(ctx, "", nil, &LoginRequest{ApiKey: apiKey, Pin: pin}, &resp); err != nil {
+		return "", err
+	}
+	return resp.Data.Token, nil
+}
+
+func NewClient(token, hardcodedEndpoint string) (*Client, error) {
+	tvdbUrl, err := restApi.ParseHostUrl(apiUrl, "v4", "/", hardcodedEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{restApi.NewClient(tvdbUrl, http.Header{"Authorization": []string{"Bearer " + token}})}, nil
+}
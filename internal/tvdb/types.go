@@ -0,0 +1,24 @@
+package tvdbApi
+
+// GenreAnime is the slug of TVDB's Anime genre
+const GenreAnime = "anime"
+
+type LoginRequest struct {
+	ApiKey string `json:"apikey"`
+	Pin    string `json:"pin,omitempty"`
+}
+
+type LoginResponse struct {
+	Data struct {
+		Token string `json:"token"`
+	} `json:"data"`
+}
+
+type SeriesExtendedResponse struct {
+	Data struct {
+		Name   string `json:"name"`
+		Genres []struct {
+			Slug string `json:"slug"`
+		} `json:"genres"`
+	} `json:"data"`
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"anime-to-seerr-blocklist/internal/console"
+	"anime-to-seerr-blocklist/internal/jellyfin"
+)
+
+// addJellyfinLibrary allowlists the series already in the Jellyfin library, which were deliberately added by the admin
+func addJellyfinLibrary(ctx context.Context, jellyfinItemsClient *jellyfinApi.Client, allowlist map[int]struct{}, verbose bool) error {
+	const limit = 1000
+
+	values := url.Values{
+		"IncludeItemTypes": []string{"Series"},
+		"Recursive":        []string{"true"},
+		"Fields":           []string{"ProviderIds"},
+		"StartIndex":       []string{""},
+		"Limit":            []string{strconv.Itoa(limit)},
+	}
+
+	for start := 0; ; start += limit {
+		var resp jellyfinApi.GetItemsResponse
+		values["StartIndex"][0] = strconv.Itoa(start)
+
+		if err := jellyfinItemsClient.Get(ctx, "", values, &resp); err != nil {
+			return err
+		}
+
+		for _, item := range resp.Items {
+			if tmdbId, err := strconv.Atoi(item.ProviderIds["Tmdb"]); err == nil {
+				if verbose {
+					console.Skipped("Allowlisting %s (%v) from Jellyfin library\n", item.Name, tmdbId)
+				}
+				allowlist[tmdbId] = struct{}{}
+			}
+		}
+
+		if len(resp.Items) == 0 || start+limit >= resp.TotalRecordCount {
+			break
+		}
+	}
+
+	return nil
+}
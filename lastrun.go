@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"anime-to-seerr-blocklist/internal/atomicfile"
+	"anime-to-seerr-blocklist/pkg/blocklistsync"
+)
+
+const lastRunFile = "last-run.json"
+
+// lastRun is the outcome of the last sync to finish, for the daemon to report on the syncs it runs in other processes
+type lastRun struct {
+	Time   time.Time            `json:"time"`
+	Report blocklistSync.Report `json:"report"`
+}
+
+func saveLastRun(cacheDir string, report blocklistSync.Report) error {
+	b, err := json.Marshal(&lastRun{Time: time.Now(), Report: report})
+	if err != nil {
+		return err
+	}
+	return atomicFile.WriteFile(filepath.Join(cacheDir, lastRunFile), b)
+}
+
+// loadLastRun returns the last sync's outcome, or nil if there hasn't been one
+func loadLastRun(cacheDir string) (*lastRun, error) {
+	b, err := os.ReadFile(filepath.Join(cacheDir, lastRunFile))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var run lastRun
+	if err = json.Unmarshal(b, &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"anime-to-seerr-blocklist/internal/seerr"
+	"anime-to-seerr-blocklist/pkg/blocklistsync"
+)
+
+func runList(ctx context.Context, opts *options, args []string) {
+	var managed, manual bool
+
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	fs.BoolVar(&managed, "managed", false, "Only list the entries syncs added")
+	fs.BoolVar(&manual, "manual", false, "Only list the entries not added by syncs, such as those made by hand in Seerr")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: anime-to-seerr-blocklist list [-managed | -manual]")
+		fmt.Fprintln(fs.Output(), "Lists the blocklist, telling the entries syncs added apart from the rest")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	if managed && manual {
+		log.Fatal("-managed and -manual can't be combined")
+	}
+
+	additions, err := blocklistSync.Additions(opts.cacheDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	seerrHost := os.Getenv("SEERR_HOST")
+	seerrApiKey := os.Getenv("SEERR_API_KEY")
+	if seerrHost == "" || seerrApiKey == "" {
+		log.Fatal("$SEERR_HOST/$SEERR_API_KEY are required")
+	}
+	seerr, err := seerrApi.NewClient(seerrHost, seerrApiKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	entries, partial, err := blocklistSync.Entries(ctx, seerr.Blocklist())
+	if err != nil {
+		log.Fatalf("Error fetching blocklist: %v", err)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "TMDB ID\tTYPE\tTITLE\tADDED BY")
+	for _, entry := range entries {
+		addition, ok := additions[entry.TmdbId]
+		ok = ok && entry.MediaType == seerrApi.MediaTypeTv
+		if (managed && !ok) || (manual && ok) {
+			continue
+		}
+
+		addedBy := "-"
+		if ok {
+			addedBy = "sync"
+			if !addition.Time.IsZero() {
+				addedBy += addition.Time.Local().Format(" on 2006-01-02 15:04:05")
+			}
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", entry.TmdbId, entry.MediaType, entry.Title, addedBy)
+	}
+	if err = tw.Flush(); err != nil {
+		log.Fatal(err)
+	}
+	if partial {
+		log.Print("The blocklist could only be fetched in part")
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+const lockFileName = "anime-to-seerr-blocklist.lock"
+const daemonLockFileName = "daemon.lock"
+
+var errLocked = errors.New("another instance is already running")
+
+// lockCacheDir stops overlapping runs from making the same changes twice or clobbering each other's files in the cache
+// directory. The lock is held until the returned file is closed or the process exits
+func lockCacheDir(cacheDir string) (*os.File, error) {
+	return lockFile(filepath.Join(cacheDir, lockFileName))
+}
+
+// lockDaemon stops a second daemon from running against the same cache directory, which would sync twice as often. It's
+// apart from lockCacheDir's lock, which the daemon's syncs take
+func lockDaemon(cacheDir string) (*os.File, error) {
+	return lockFile(filepath.Join(cacheDir, daemonLockFileName))
+}
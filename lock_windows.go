@@ -0,0 +1,27 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+const errorSharingViolation syscall.Errno = 32
+
+// Windows doesn't let a file opened without sharing be opened again until it's closed, which is as good as a lock
+func lockFile(filename string) (*os.File, error) {
+	name, err := syscall.UTF16PtrFromString(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := syscall.CreateFile(name, syscall.GENERIC_READ|syscall.GENERIC_WRITE, 0, nil, syscall.OPEN_ALWAYS, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+	if err != nil {
+		if errors.Is(err, errorSharingViolation) {
+			return nil, errLocked
+		}
+		return nil, &os.PathError{Op: "open", Path: filename, Err: err}
+	}
+
+	return os.NewFile(uintptr(h), filename), nil
+}
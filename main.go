@@ -1,6 +1,8 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"encoding/xml"
 	"errors"
 	"flag"
@@ -12,149 +14,609 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
-	"strconv"
+	"slices"
+	"strings"
+	"syscall"
 	"time"
 
-	"codeberg.org/sdassow/atomic"
-	"github.com/joho/godotenv"
-
 	"anime-to-seerr-blocklist/internal/anime-list"
+	"anime-to-seerr-blocklist/internal/arr"
+	"anime-to-seerr-blocklist/internal/console"
+	"anime-to-seerr-blocklist/internal/ombi"
+	"anime-to-seerr-blocklist/internal/rest"
 	"anime-to-seerr-blocklist/internal/seerr"
+	"anime-to-seerr-blocklist/pkg/blocklistsync"
 )
 
-const updateInterval = 24 * time.Hour
-const mappingURL = "https://raw.githubusercontent.com/Anime-Lists/anime-lists/master/anime-list.xml"
+// The variants of the mapping: the full one also has the entries the reduced one omits
+var mappingURLs = map[string]string{
+	"reduced": "https://raw.githubusercontent.com/Anime-Lists/anime-lists/master/anime-list.xml",
+	"full":    "https://raw.githubusercontent.com/Anime-Lists/anime-lists/master/anime-list-full.xml",
+}
 
-func fetchAndParseAnimeList(cacheDir string) ([]AnimeList.Anime, error) {
-	var animeList AnimeList.AnimeList
+// parseAnimeList decodes the mapping as JSON or XML, whichever it turns out to be
+func parseAnimeList(r io.Reader, keep func(p *AnimeList.Anime) bool) ([]AnimeList.Anime, error) {
+	br := bufio.NewReader(r)
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			_, _ = br.ReadByte()
+			continue
+		case '[':
+			return parseAnimeListJSON(br, keep)
+		}
+		return parseAnimeListXML(br, keep)
+	}
+}
 
-	filename := filepath.Join(cacheDir, filepath.Base(mappingURL))
+// parseAnimeListXML decodes the mapping one entry at a time, keeping only those keep accepts so that the whole document
+// never has to be held in memory
+func parseAnimeListXML(r io.Reader, keep func(p *AnimeList.Anime) bool) ([]AnimeList.Anime, error) {
+	var fdp []AnimeList.Anime
 
-	if fi, statErr := os.Stat(filename); statErr == nil && time.Since(fi.ModTime()) < updateInterval {
-		file, err := os.Open(filename)
+	// Only the predefined entities are expanded, as d.Entity is left unset, and DTDs are never processed, so the
+	// mapping's size bounds the memory decoding it takes
+	d := xml.NewDecoder(r)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
 		if err != nil {
 			return nil, err
 		}
+
+		se, ok := tok.(xml.StartElement)
+		if !ok || se.Name.Local != "anime" {
+			continue
+		}
+
+		var p AnimeList.Anime
+		if err = d.DecodeElement(&p, &se); err != nil {
+			return nil, err
+		}
+		if keep(&p) {
+			fdp = append(fdp, p)
+		}
+	}
+
+	return slices.Clip(fdp), nil
+}
+
+func fetchAndParseAnimeList(ctx context.Context, cacheDir, variant string, policy cachePolicy, keep func(p *AnimeList.Anime) bool) ([]AnimeList.Anime, error) {
+	var fdp []AnimeList.Anime
+
+	mappingURL, ok := mappingURLs[variant]
+	if !ok {
+		return nil, fmt.Errorf("unknown mapping variant %q, expected reduced or full", variant)
+	}
+
+	err := fetchCached(ctx, cacheDir, mappingURL, policy, func(r io.Reader) (err error) {
+		fdp, err = parseAnimeList(r, keep)
+		return
+	})
+	if err != nil {
+		// Without a cached copy to fall back on next time either, use the snapshot embedded at build time if any
+		if _, statErr := os.Stat(cachedFilename(cacheDir, mappingURL)); !errors.Is(statErr, fs.ErrNotExist) {
+			return nil, err
+		}
+		file, fallbackErr := openFallback(mappingURL)
+		if fallbackErr != nil {
+			return nil, err
+		}
 		defer file.Close()
 
-		if err := xml.NewDecoder(file).Decode(&animeList); err != nil {
+		log.Printf("Error fetching %s, using the embedded snapshot: %v", urlBase(mappingURL), err)
+		if fdp, err = parseAnimeList(file, keep); err != nil {
 			return nil, err
 		}
-	} else {
-		req, err := http.NewRequest(http.MethodGet, mappingURL, nil)
+	}
+
+	return fdp, nil
+}
+
+// readAnimeList reads the mapping and applies overrides to it. The entries that only the overrides have are left for
+// the caller to add once every source is read
+func readAnimeList(ctx context.Context, opts *options, overrides *mappingOverrides, keep func(p *AnimeList.Anime) bool) ([]AnimeList.Anime, error) {
+	return readMappingFile(ctx, opts, func(p *AnimeList.Anime) bool {
+		return overrides.apply(p) && keep(p)
+	})
+}
+
+// readMappingFile parses the mapping from opts' -mapping-file, fetching it if that's a URL, or fetches -mapping-variant
+// if not given
+func readMappingFile(ctx context.Context, opts *options, keep func(p *AnimeList.Anime) bool) ([]AnimeList.Anime, error) {
+	switch opts.mappingFile {
+	case "":
+		return fetchAndParseAnimeList(ctx, opts.cacheDir, opts.mappingVariant, opts.mappingCache, keep)
+	case "-":
+		return parseAnimeList(os.Stdin, keep)
+	}
+
+	if isUrl(opts.mappingFile) {
+		var fdp []AnimeList.Anime
+		err := fetchCached(ctx, opts.cacheDir, opts.mappingFile, opts.mappingCache, func(r io.Reader) (err error) {
+			fdp, err = parseAnimeList(r, keep)
+			return
+		})
+		return fdp, err
+	}
+
+	file, err := os.Open(opts.mappingFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parseAnimeList(file, keep)
+}
+
+// loadMapping fetches the mapping, merges the series of other sources into it and drops allowlisted series from it
+func loadMapping(ctx context.Context, opts *options) ([]AnimeList.Anime, error) {
+	overrides, err := loadMappingOverrides(opts.mappingOverrides, opts.verbose)
+	if err != nil {
+		return nil, err
+	}
+
+	merge := newMappingMerge(opts.verbose)
+	var heuristicFdp []AnimeList.Anime
+	for source := range strings.SplitSeq(opts.sources, ",") {
+		var sourceFdp []AnimeList.Anime
+
+		switch source {
+		case "anime-lists":
+			sourceFdp, err = readAnimeList(ctx, opts, overrides, func(p *AnimeList.Anime) bool {
+				if droppedCategory(opts, p) {
+					// Or another source could add it back
+					merge.exclude(p.Anidbid, source)
+					return false
+				}
+				return true
+			})
+		case "anime-offline-database":
+			sourceFdp, err = fetchOfflineDatabase(ctx, opts, overrides)
+		case "shoko":
+			sourceFdp, err = fetchShokoSeries(ctx)
+		case "tmdb-keyword":
+			sourceFdp, err = fetchTmdbKeyword(ctx)
+		case "tmdb-heuristic":
+			// Merged last, after reporting what only it found
+			heuristicFdp, err = fetchTmdbHeuristic(ctx)
+		default:
+			if isIdListSource(source) {
+				sourceFdp, err = fetchIdList(ctx, opts, source)
+				break
+			}
+			err = fmt.Errorf("unknown source %q", source)
+		}
 		if err != nil {
 			return nil, err
 		}
 
-		resp, err := http.DefaultClient.Do(req)
+		merge.add(source, sourceFdp)
+	}
+	merge.add("-mapping-overrides", overrides.additions())
+	fdp := merge.mapping()
+	fdp = append(fdp, opts.extraBlocklist...)
+	if heuristicFdp != nil {
+		reportHeuristicOnly(heuristicFdp, fdp)
+		fdp = append(fdp, heuristicFdp...)
+	}
+
+	fdp = dropCategories(opts, fdp)
+
+	if anidbFiltered(opts) {
+		anidbCache, err := openAnidbCache(opts.cacheDir, opts.anidbMaxLookups)
 		if err != nil {
 			return nil, err
 		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+		fdp = dropByAnidbDetails(ctx, opts, fdp, anidbCache)
+		if err = anidbCache.save(); err != nil {
+			return nil, err
 		}
+	}
 
-		// https://github.com/natefinch/atomic/blob/master/atomic.go
-		dir, file := filepath.Split(filename)
-		if dir == "" {
-			dir = "."
+	if opts.verifyTvdb {
+		tvdbCache, err := openTvdbSeriesCache(opts.cacheDir)
+		if err != nil {
+			return nil, err
+		}
+		fdp = dropNonTvdbAnime(ctx, fdp, tvdbCache, opts.verbose)
+		if err = tvdbCache.save(); err != nil {
+			return nil, err
 		}
+	}
 
-		f, err := os.CreateTemp(dir, file)
+	if opts.skipMixedSeries || opts.allowPopularAbove > 0 {
+		tmdbCache, err := openTmdbTvCache(opts.cacheDir)
 		if err != nil {
-			return nil, fmt.Errorf("cannot create temp file: %v", err)
+			return nil, err
 		}
-		defer func() {
-			if err != nil {
-				_ = os.Remove(f.Name())
-			}
-		}()
-		defer f.Close()
-		fname := f.Name()
 
-		r := io.TeeReader(resp.Body, f)
-		err = xml.NewDecoder(r).Decode(&animeList)
-		if err != nil {
+		if opts.skipMixedSeries {
+			fdp = dropMixedSeries(ctx, fdp, tmdbCache, opts.verbose)
+		}
+		if opts.allowPopularAbove > 0 {
+			allowlistPopular(ctx, fdp, tmdbCache, opts.popularityMetric, opts.allowPopularAbove, opts.allowlist, opts.verbose)
+		}
+
+		if err = tmdbCache.save(); err != nil {
 			return nil, err
 		}
+	}
 
-		err = f.Sync()
-		if err != nil {
-			return nil, fmt.Errorf("cannot flush tempfile %q: %v", fname, err)
+	// Series allowlisted by AniDB or TVDB ID are also allowlisted by TMDB ID so that they're unblocked and their other
+	// AniDB entries are dropped too
+	for _, p := range fdp {
+		if p.Tmdbtv == 0 {
+			continue
 		}
-		err = f.Close()
-		if err != nil {
-			return nil, fmt.Errorf("cannot close tempfile %q: %v", fname, err)
+		if allowlistedByOtherId(opts, &p) {
+			opts.allowlist[p.Tmdbtv] = struct{}{}
 		}
+	}
 
-		if statErr == nil {
-			if fileMode := fi.Mode(); fileMode != 0 {
-				err = os.Chmod(fname, fileMode)
-				if err != nil {
-					return nil, fmt.Errorf("cannot set filemode on tempfile %q: %v", fname, err)
-				}
-			}
+	if len(opts.allowlist) == 0 && len(opts.allowlistAnidb) == 0 && len(opts.allowlistTvdb) == 0 {
+		return fdp, nil
+	}
+	return slices.DeleteFunc(fdp, func(p AnimeList.Anime) bool {
+		if _, ok := opts.allowlist[p.Tmdbtv]; ok && p.Tmdbtv != 0 {
+			return true
 		}
-		err = atomic.ReplaceFile(fname, filename)
-		if err != nil {
-			return nil, fmt.Errorf("cannot replace %q with tempfile %q: %v", filename, fname, err)
+		return allowlistedByOtherId(opts, &p)
+	}), nil
+}
+
+func allowlistedByOtherId(opts *options, p *AnimeList.Anime) bool {
+	if _, ok := opts.allowlistAnidb[p.Anidbid]; ok {
+		return true
+	}
+	if tvdbId, ok := seriesTvdbId(p); ok {
+		if _, ok = opts.allowlistTvdb[tvdbId]; ok {
+			return true
 		}
 	}
+	return false
+}
 
-	return animeList.Anime, nil
+func animeTmdbIdSet(fdp []AnimeList.Anime) map[int]struct{} {
+	animeTmdbIds := make(map[int]struct{}, len(fdp))
+	for _, p := range fdp {
+		if p.Tmdbtv != 0 {
+			animeTmdbIds[p.Tmdbtv] = struct{}{}
+		}
+	}
+	return animeTmdbIds
 }
 
-func getAlreadyBlocklisted(seerrBlocklistClient *seerrApi.Client) (blocklisted map[int]struct{}, err error) {
-	const take = math.MaxInt16 // 100
-	skip := 0
+// uniqueSeries returns one entry per TMDB series from the mapping, in which sequels and seasons each have their own
+// AniDB entry. The entry for the first season is preferred as its title is usually that of the series as a whole,
+// otherwise the shortest title is used
+func uniqueSeries(fdp []AnimeList.Anime) []AnimeList.Anime {
+	index := make(map[int]int, len(fdp))
+	unique := make([]AnimeList.Anime, 0, len(fdp))
 
-	values := url.Values{
-		"take": []string{strconv.Itoa(take)},
-		"skip":   []string{""},
-		"filter": []string{seerrApi.GetBlocklistParamsFilterAll},
-		//"search": []string{""},
+	isFirstSeason := func(p *AnimeList.Anime) bool {
+		return p.Tmdbseason == "1" || p.Tmdbseason == "a"
 	}
 
-	for {
-		var resp seerrApi.GetBlocklistResponse
-		values["skip"][0] = strconv.Itoa(skip)
+	for _, p := range fdp {
+		if p.Tmdbtv == 0 {
+			continue
+		}
 
-		err = seerrBlocklistClient.Get("", values, &resp)
-		if err != nil {
-			return
+		i, ok := index[p.Tmdbtv]
+		if !ok {
+			index[p.Tmdbtv] = len(unique)
+			unique = append(unique, p)
+			continue
+		}
+
+		best := &unique[i]
+		if isFirstSeason(best) {
+			continue
 		}
+		if isFirstSeason(&p) || best.Name == "" || (p.Name != "" && len(p.Name) < len(best.Name)) {
+			*best = p
+		}
+	}
+
+	return unique
+}
+
+type options struct {
+	cacheDir         string
+	envFile          string
+	mappingCache     cachePolicy
+	mappingFile      string
+	mappingVariant   string
+	mappingOverrides string
+	incremental      bool
+	verify           bool
+	verifyDrift      bool
+	emitScript       string
+	interactive      bool
+	force            bool
+	verbose          bool
+	timeout          time.Duration
+	daemon           bool
+	interval         time.Duration
+	runOnStart       bool
+	scheduleJitter   time.Duration
+	syncAt           string
+	location         *time.Location
+	watch            bool
+	pprof            string
+	apiListen        string
+	maxAdditions     int
+	rateLimit        float64
+	rateBurst        int
+	retryMaxAttempts int
+	failFast         int
+	target           string
+	allUsers         bool
+	blocklistKeyword bool
+	skipTitles       bool
+	overrideRule     seerrApi.OverrideRule
+	overrideSonarrId int
+	restrictUserIds  string
+	cleanWatchlists  bool
+	sonarr           bool
+	radarr           bool
+	allowlistSonarr  string
+	allowlistShoko   bool
+	allowlistList    string
+	allowlistMaxAge  time.Duration
+	userAllowlists   string
+	skipAvailable    bool
+	sources          string
+	skipMixedSeries  bool
+	verifyTvdb       bool
+
+	anidbTypes      string
+	minEpisodes     int
+	skipRestricted  bool
+	anidbMaxLookups int
+
+	allowlistPlex     bool
+	allowlistJellyfin bool
+	allowlistAnilist  bool
+	allowlistMal      bool
+	allowlistSimkl    bool
+	allowlistTrakt    bool
+	allowlistMdblist  bool
+	blocklistTrakt    bool
+	blocklistMdblist  bool
+
+	allowPopularAbove float64
+	popularityMetric  string
+
+	includeAdult         bool
+	adultOnly            bool
+	skipUnmappedSpecials bool
+
+	allowlist      map[int]struct{}
+	allowlistAnidb map[int]struct{}
+	allowlistTvdb  map[int]struct{}
+	extraBlocklist []AnimeList.Anime
+}
+
+func runSeerr(ctx context.Context, opts *options) []AnimeList.Anime {
+	start := time.Now()
+	seerrHost := os.Getenv("SEERR_HOST")
+	seerrApiKey := os.Getenv("SEERR_API_KEY")
+	seerrUserIds, err := parseIds(os.Getenv("SEERR_USER_ID"))
+	if seerrHost == "" || seerrApiKey == "" || err != nil || (len(seerrUserIds) == 0 && !opts.allUsers) {
+		log.Fatal("$SEERR_HOST/$SEERR_API_KEY/$SEERR_USER_ID are required")
+	}
+	seerr, err := seerrApi.NewClient(seerrHost, seerrApiKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	server, err := detectSeerrServer(ctx, seerr, opts.cacheDir, seerrHost)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = server.checkFeatures(opts); err != nil {
+		log.Fatal(err)
+	}
 
-		pageInfo := resp.PageInfo
-		if skip == 0 && blocklisted == nil {
-			blocklisted = make(map[int]struct{}, pageInfo.Results)
+	if opts.skipAvailable {
+		if err = allowlistAvailable(ctx, seerr.Media(), opts.allowlist, opts.verbose); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if opts.userAllowlists != "" {
+		if err = addUserAllowlists(ctx, opts, seerr.Users()); err != nil {
+			log.Fatal(err)
 		}
+	}
 
-		for _, result := range resp.Results {
-			if result.MediaType == seerrApi.MediaTypeTv {
-				blocklisted[result.TmdbId] = struct{}{}
+	fdp, err := loadMapping(ctx, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if !opts.skipTitles {
+		if opts.allUsers {
+			seerrUserClient := seerr.Users()
+
+			users, err := getUsers(ctx, seerrUserClient)
+			if err != nil {
+				log.Fatal(err)
+			}
+			seerrUserIds = seerrUserIds[:0]
+			for _, user := range users {
+				seerrUserIds = append(seerrUserIds, user.Id)
+			}
+			if len(seerrUserIds) == 0 {
+				log.Fatal("no users found")
 			}
 		}
 
-		if pageInfo.Page >= pageInfo.Pages {
-			break
+		unique := uniqueSeries(fdp)
+		series := make([]blocklistSync.Series, 0, len(unique))
+		for _, p := range unique {
+			series = append(series, blocklistSync.Series{TmdbId: p.Tmdbtv, Title: p.Name})
 		}
 
-		if len(resp.Results) == 0 {
-			break
+		cfg := blocklistSync.Config{
+			SeerrHost:        seerrHost,
+			SeerrApiKey:      seerrApiKey,
+			UserIds:          seerrUserIds,
+			Series:           series,
+			Allowlist:        opts.allowlist,
+			StateDir:         opts.cacheDir,
+			TmdbApiKey:       os.Getenv("TMDB_API_KEY"),
+			WriteRateLimit:   opts.rateLimit,
+			WriteBurst:       opts.rateBurst,
+			MaxAdditions:     opts.maxAdditions,
+			RetryMaxAttempts: opts.retryMaxAttempts,
+			FailFast:         opts.failFast,
+			Incremental:      opts.incremental,
+			Verify:           opts.verify,
+			Force:            opts.force,
+			Verbose:          opts.verbose,
+		}
+		if opts.verifyDrift {
+			drift, err := blocklistSync.CheckDrift(ctx, cfg)
+			if err != nil {
+				log.Fatal(err)
+			}
+			writeDrift(os.Stdout, &drift)
+			return fdp
+		}
+		if opts.emitScript != "" {
+			script, err := newScriptWriter(os.Stdout, opts.emitScript, seerr.Blocklist())
+			if err != nil {
+				log.Fatal(err)
+			}
+			cfg.Blocklist = script
+			// Nothing is actually done, so mustn't be recorded as such
+			if cfg.StateDir, err = os.MkdirTemp("", "anime-to-seerr-blocklist"); err != nil {
+				log.Fatal(err)
+			}
+			defer os.RemoveAll(cfg.StateDir)
+
+			if _, err = blocklistSync.Sync(ctx, cfg); err != nil {
+				log.Fatal(err)
+			}
+			return fdp
+		}
+		cfg.Bulk = server.BulkBlocklist
+		if opts.interactive {
+			cfg.Approve = approveInteractively(os.Stdin, os.Stdout)
+		}
+		// Verbose output would break up the bar
+		if !opts.verbose && console.IsTerminal(os.Stderr) {
+			cfg.Progress = (&progressBar{w: os.Stderr}).update
+		}
+		var report blocklistSync.Report
+		err = blocklistSync.ErrNoBlocklist
+		if server.Blocklist {
+			report, err = blocklistSync.Sync(ctx, cfg)
+		}
+		if errors.Is(err, blocklistSync.ErrNoBlocklist) {
+			// Overseerr
+			log.Printf("Server %s has no blocklist, declining pending requests for anime instead", server.versionString())
+			seerrRequestClient := seerr.Requests()
+
+			if err = declineAnimeRequests(ctx, seerrRequestClient, animeTmdbIdSet(fdp), opts.verbose); err != nil {
+				log.Fatal(err)
+			}
+		} else if err != nil {
+			log.Fatal(err)
+		} else {
+			if report.UpToDate && opts.verbose {
+				fmt.Println("No changes since the last sync")
+			}
+			if err = saveLastRun(opts.cacheDir, report); err != nil {
+				log.Printf("Error saving sync report: %v", err)
+			}
+			if err = appendHistory(opts.cacheDir, start, len(fdp), &report); err != nil {
+				log.Printf("Error saving sync history: %v", err)
+			}
+			if report.Aborted {
+				log.Fatal("Sync aborted by -fail-fast")
+			}
+		}
+	}
+
+	if opts.blocklistKeyword {
+		seerrSettingsClient := seerr.Settings()
+
+		if err = addDiscoverKeyword(ctx, seerrSettingsClient, opts.verbose); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if opts.overrideSonarrId >= 0 {
+		seerrOverrideRuleClient := seerr.OverrideRules()
+
+		opts.overrideRule.SonarrServiceId = &opts.overrideSonarrId
+		if err = upsertOverrideRule(ctx, seerrOverrideRuleClient, opts.overrideRule, opts.verbose); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if opts.restrictUserIds != "" {
+		userIds, err := parseIds(opts.restrictUserIds)
+		if err != nil {
+			log.Fatalf("-restrict-users: %v", err)
+		}
+
+		seerrUserClient := seerr.Users()
+
+		restrictUsers(ctx, seerrUserClient, userIds, opts.verbose)
+	}
+
+	if opts.cleanWatchlists {
+		seerrUserClient := seerr.Users()
+		seerrWatchlistClient := seerr.Watchlist()
+
+		if err = cleanWatchlists(ctx, seerrUserClient, seerrWatchlistClient, animeTmdbIdSet(fdp), opts.verbose); err != nil {
+			log.Fatal(err)
 		}
+	}
+
+	return fdp
+}
+
+func runOmbi(ctx context.Context, opts *options) []AnimeList.Anime {
+	ombiHost := os.Getenv("OMBI_HOST")
+	ombiApiKey := os.Getenv("OMBI_API_KEY")
+	if ombiHost == "" || ombiApiKey == "" {
+		log.Fatal("$OMBI_HOST/$OMBI_API_KEY are required")
+	}
+
+	ombiSettingsClient, err := ombiApi.NewClient(ombiHost, ombiApiKey, "Settings")
+	if err != nil {
+		log.Fatal(err)
+	}
+	ombiRequestClient, err := ombiApi.NewClient(ombiHost, ombiApiKey, "Request")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fdp, err := loadMapping(ctx, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-		skip += take
+	if err = syncOmbi(ctx, ombiSettingsClient, ombiRequestClient, fdp, opts.verbose); err != nil {
+		log.Fatal(err)
 	}
 
-	return
+	return fdp
 }
 
 func main() {
-	var cacheDir string
-	var verbose bool
+	opts := options{overrideSonarrId: -1}
 
 	exe, err := os.Executable()
 	if err != nil {
@@ -162,71 +624,353 @@ func main() {
 	}
 	exe = filepath.Dir(exe)
 
-	flag.StringVar(&cacheDir, "cache-dir", exe, "Folder to store downloaded files in")
-	flag.BoolVar(&verbose, "verbose", false, "Verbose output")
+	defaultCacheDir := exe
+	if userCacheDir, err := os.UserCacheDir(); err == nil {
+		defaultCacheDir = filepath.Join(userCacheDir, "anime-to-seerr-blocklist")
+	}
+
+	flag.StringVar(&opts.cacheDir, "cache-dir", defaultCacheDir, "Folder to store downloaded files in")
+	flag.StringVar(&opts.envFile, "env-file", "", "Load configuration from this .env file only")
+	flag.StringVar(&opts.mappingVariant, "mapping-variant", "reduced", "Variant of the anime-lists mapping to download: reduced, or full for the entries the reduced one omits too")
+	flag.StringVar(&opts.mappingFile, "mapping-file", "", "Read the anime-lists mapping, as XML or a JSON array of entries with the XML's attributes as keys, from this file, or - for stdin, instead of downloading -mapping-variant. A URL, e.g. of a JSON export, is downloaded and cached like the mapping")
+	flag.StringVar(&opts.mappingOverrides, "mapping-overrides", "", "JSON file of local corrections to the mapping, e.g. [{\"anidbid\": 12345, \"tmdbtv\": 67890}, {\"anidbid\": 23456, \"ignore\": true}]")
+	flag.DurationVar(&opts.mappingCache.maxAge, "mapping-max-age", 24*time.Hour, "Download the anime mappings again once the cached copies are older than this")
+	flag.BoolVar(&opts.mappingCache.offline, "offline", false, "Use the cached anime mappings whatever their age and never download them")
+	flag.BoolVar(&opts.mappingCache.force, "force-refresh", false, "Download the anime mappings again regardless of the age of the cached copies")
+	proxy := flag.String("proxy", "", "Proxy to send requests through, e.g. socks5://localhost:1080, instead of $HTTPS_PROXY etc.")
+	var tlsOpts tlsOptions
+	flag.StringVar(&tlsOpts.caCert, "ca-cert", "", "PEM file of additional CA certificates to trust for Seerr and the other servers")
+	flag.StringVar(&tlsOpts.clientCert, "client-cert", "", "PEM file of the client certificate to present to Seerr and the other servers")
+	flag.StringVar(&tlsOpts.clientKey, "client-key", "", "PEM file of the key for -client-cert")
+	flag.BoolVar(&tlsOpts.insecureSkipVerify, "insecure-skip-verify", false, "Don't verify the certificates of Seerr and the other servers (insecure)")
+	flag.Func("header", "Extra \"Name: value\" header to send to Seerr, may be repeated", func(s string) error {
+		return addHeader(seerrApi.ExtraHeader, s)
+	})
+	timeouts := restApi.DefaultTimeouts
+	flag.DurationVar(&timeouts.Dial, "dial-timeout", timeouts.Dial, "Give up connecting to a server after this long, 0 for no limit")
+	flag.DurationVar(&timeouts.KeepAlive, "keep-alive", timeouts.KeepAlive, "Interval between TCP keep-alive probes, negative to disable them")
+	flag.DurationVar(&timeouts.ResponseHeader, "response-header-timeout", timeouts.ResponseHeader, "Give up waiting for a server to start responding after this long, 0 for no limit")
+	flag.DurationVar(&timeouts.IdleConn, "idle-conn-timeout", timeouts.IdleConn, "Close connections left idle for this long, 0 for no limit")
+	flag.DurationVar(&timeouts.Request, "request-timeout", timeouts.Request, "Give up on a request, including reading the response, after this long, 0 for no limit")
+	http2 := flag.Bool("http2", false, "Use HTTP/2 with servers that support it")
+	maxIdleConnsPerHost := flag.Int("max-idle-conns-per-host", http.DefaultMaxIdleConnsPerHost, "Idle connections to each server to keep for reuse")
+	ipVersion := flag.Int("ip-version", 0, "Only connect over IPv4 or IPv6: 4 or 6")
+	dnsServer := flag.String("dns-server", "", "DNS server to resolve hosts with instead of the system's resolver")
+	flag.Func("resolve", "host=IP mapping to connect to instead of resolving the host, may be repeated", func(s string) error {
+		host, ip, ok := strings.Cut(s, "=")
+		if !ok {
+			return errors.New("expected host=IP")
+		}
+		return restApi.AddHostMapping(host, ip)
+	})
+	flag.BoolVar(&opts.incremental, "incremental", false, "Only blocklist series added to the mapping since the last finished sync, skipping fetching the blocklist if there are none")
+	flag.StringVar(&opts.emitScript, "emit-script", "", "Instead of changing the blocklist, write the requests a sync would make to stdout as a curl or httpie script")
+	flag.BoolVar(&opts.force, "force", false, "Allow removing blocklist entries that syncs didn't add, when they're allowlisted, in the way of a series or being rolled back")
+	flag.BoolVar(&opts.verifyDrift, "verify-drift", false, "Instead of syncing, report blocklist entries removed by hand, missing despite having been synced, or not from the mapping")
+	flag.BoolVar(&opts.verify, "verify", false, "Fetch the blocklist again after syncing to check that the series added are on it")
+	flag.BoolVar(&opts.interactive, "interactive", false, "Review the series to add to the blocklist in batches before adding them")
+	flag.BoolVar(&opts.verbose, "verbose", false, "Verbose output")
+	color := flag.String("color", "auto", "Color the output: auto (when it's a terminal and $NO_COLOR isn't set), always or never")
+	printVersion := flag.Bool("version", false, "Print the version and build details, then exit")
+	flag.DurationVar(&opts.timeout, "timeout", 0, "Give up on the run after this long")
+	flag.BoolVar(&opts.daemon, "daemon", false, "Keep running, syncing every -interval, or $SYNC_INTERVAL which SIGHUP reloads. Other settings in the .env files apply from the next sync, but flags need a restart")
+	flag.DurationVar(&opts.interval, "interval", 24*time.Hour, "Time between syncs with -daemon")
+	flag.BoolVar(&opts.runOnStart, "run-on-start", true, "With -daemon, sync on starting instead of waiting for the first scheduled sync")
+	flag.DurationVar(&opts.scheduleJitter, "schedule-jitter", 0, "With -daemon, delay each scheduled sync by a random time up to this, so that many instances don't sync at once")
+	flag.StringVar(&opts.syncAt, "sync-at", "", "With -daemon, sync daily at this HH:MM instead of every -interval")
+	timezone := flag.String("timezone", "", "IANA time zone of -sync-at, e.g. Europe/London, instead of the local one")
+	flag.BoolVar(&opts.watch, "watch", false, "Keep running, also syncing as soon as the local files of -allowlist, -mapping-file, -mapping-overrides or -user-allowlists change")
+	flag.StringVar(&opts.apiListen, "api-listen", "", "With -daemon, serve a dashboard and an API to trigger and inspect syncs at this address, authenticated with $DAEMON_API_KEY")
+	flag.StringVar(&opts.pprof, "pprof", "", "Serve runtime profiles at this address, e.g. localhost:6060, while syncing")
+	flag.Float64Var(&opts.rateLimit, "rate-limit", 0, "Make at most this many changes to Seerr's blocklist per second, 0 for no limit")
+	flag.IntVar(&opts.rateBurst, "rate-burst", 1, "Changes to allow at once under -rate-limit")
+	flag.IntVar(&opts.maxAdditions, "max-additions", 0, "Stop after adding this many series to the blocklist, 0 for no limit")
+	flag.IntVar(&opts.failFast, "fail-fast", 0, "Abort the sync after this many changes to the blocklist fail in a row, 0 to carry on regardless")
+	flag.IntVar(&opts.retryMaxAttempts, "retry-max-attempts", 5, "Give up retrying a series that keeps failing to be added after this many runs, 0 to never give up")
+	flag.StringVar(&opts.sources, "source", "anime-lists", "Comma-separated sources of anime to blocklist: anime-lists, anime-offline-database, shoko, tmdb-keyword, tmdb-heuristic, or the URL of a list of TMDB IDs, one per line. Where they map an AniDB entry differently, the first listed wins")
+	flag.StringVar(&opts.target, "target", "seerr", "Server to apply the blocklist to: seerr or ombi")
+	flag.BoolVar(&opts.allUsers, "all-users", false, "Attribute blocklist entries to all Seerr users instead of $SEERR_USER_ID")
+	flag.BoolVar(&opts.blocklistKeyword, "blocklist-keyword", false, "Also add TMDB's anime keyword to Seerr's blocklisted tags to hide anime from Discover")
+	flag.BoolVar(&opts.skipTitles, "skip-titles", false, "Don't blocklist individual titles")
+	flag.IntVar(&opts.overrideSonarrId, "override-sonarr-id", opts.overrideSonarrId, "Create or update a Seerr override rule routing anime to this Sonarr server")
+	flag.IntVar(&opts.overrideRule.ProfileId, "override-profile-id", 0, "Quality profile for the override rule")
+	flag.StringVar(&opts.overrideRule.RootFolder, "override-root-folder", "", "Root folder for the override rule")
+	flag.StringVar(&opts.overrideRule.Tags, "override-tags", "", "Comma-separated Sonarr tag IDs for the override rule")
+	flag.StringVar(&opts.restrictUserIds, "restrict-users", "", "Comma-separated IDs of users to remove series request permissions from")
+	flag.BoolVar(&opts.cleanWatchlists, "clean-watchlists", false, "Also remove anime from every user's watchlist")
+	flag.BoolVar(&opts.sonarr, "sonarr", false, "Also add anime to Sonarr's import list exclusions")
+	flag.BoolVar(&opts.radarr, "radarr", false, "Also add anime movies to Radarr's list exclusions")
+	flag.StringVar(&opts.allowlistList, "allowlist", "", "Don't blocklist the series on this file or URL listing their TMDB IDs, one per line")
+	flag.DurationVar(&opts.allowlistMaxAge, "allowlist-max-age", time.Hour, "Download a remote -allowlist again once the cached copy is older than this")
+	flag.StringVar(&opts.userAllowlists, "user-allowlists", "", "JSON file of Seerr user IDs or emails to each user's -allowlist, whose series aren't blocklisted while the user exists")
+	flag.BoolVar(&opts.skipAvailable, "skip-available", false, "Don't blocklist series already available in Seerr, and unblock those that are")
+	flag.StringVar(&opts.allowlistSonarr, "allowlist-sonarr", "", "Don't blocklist series monitored in Sonarr: monitored, or anime for only anime-type series")
+	flag.BoolVar(&opts.allowlistShoko, "allowlist-shoko", false, "Don't blocklist anime in Shoko Server's collection, at $SHOKO_HOST with $SHOKO_API_KEY")
+	flag.BoolVar(&opts.allowlistPlex, "allowlist-plex", false, "Don't blocklist series on the Plex watchlist of the account of $PLEX_TOKEN")
+	flag.BoolVar(&opts.allowlistJellyfin, "allowlist-jellyfin", false, "Don't blocklist series in the Jellyfin library at $JELLYFIN_HOST with $JELLYFIN_API_KEY")
+	flag.BoolVar(&opts.allowlistAnilist, "allowlist-anilist", false, "Don't blocklist anime on the AniList lists of $ANILIST_USERNAME")
+	flag.BoolVar(&opts.allowlistMal, "allowlist-mal", false, "Don't blocklist anime on the MyAnimeList lists of $MAL_USERNAME, read with $MAL_CLIENT_ID")
+	flag.BoolVar(&opts.allowlistSimkl, "allowlist-simkl", false, "Don't blocklist anime on your Simkl lists, read with $SIMKL_CLIENT_ID after simkl-login")
+	flag.BoolVar(&opts.allowlistTrakt, "allowlist-trakt", false, "Don't blocklist series on the Trakt list $TRAKT_ALLOWLIST, read with $TRAKT_CLIENT_ID")
+	flag.BoolVar(&opts.allowlistMdblist, "allowlist-mdblist", false, "Don't blocklist series on the MDBList list $MDBLIST_ALLOWLIST, read with $MDBLIST_API_KEY")
+	flag.BoolVar(&opts.blocklistTrakt, "blocklist-trakt", false, "Also blocklist series on the Trakt list $TRAKT_BLOCKLIST, read with $TRAKT_CLIENT_ID")
+	flag.BoolVar(&opts.blocklistMdblist, "blocklist-mdblist", false, "Also blocklist series on the MDBList list $MDBLIST_BLOCKLIST, read with $MDBLIST_API_KEY")
+	flag.BoolVar(&opts.skipMixedSeries, "skip-mixed-series", false, "Don't blocklist series with seasons that aren't anime (requires $TMDB_API_KEY)")
+	flag.StringVar(&opts.anidbTypes, "anidb-types", "", "Only blocklist anime of these comma-separated AniDB types, e.g. TV Series,Web (requires $ANIDB_CLIENT/$ANIDB_CLIENT_VERSION)")
+	flag.IntVar(&opts.minEpisodes, "min-episodes", 0, "Don't blocklist anime with fewer episodes than this on AniDB (requires $ANIDB_CLIENT/$ANIDB_CLIENT_VERSION)")
+	flag.BoolVar(&opts.skipRestricted, "skip-restricted", false, "Don't blocklist anime restricted on AniDB, which the mapping doesn't always mark as adult (requires $ANIDB_CLIENT/$ANIDB_CLIENT_VERSION)")
+	flag.IntVar(&opts.anidbMaxLookups, "anidb-max-lookups", defaultAnidbMaxLookups, "Look up at most this many anime on AniDB per run, as it only allows one request every few seconds, 0 for no limit")
+	flag.BoolVar(&opts.verifyTvdb, "verify-tvdb", false, "Don't blocklist series that TVDB doesn't have in its Anime genre, as a second opinion on the mapping (requires $TVDB_API_KEY)")
+	flag.Float64Var(&opts.allowPopularAbove, "allow-popular-above", 0, "Don't blocklist series more popular than this on TMDB (requires $TMDB_API_KEY)")
+	flag.StringVar(&opts.popularityMetric, "popularity-metric", "votes", "TMDB measure of popularity for -allow-popular-above: votes or popularity")
+	flag.BoolVar(&opts.includeAdult, "include-adult", true, "Blocklist adult anime")
+	flag.BoolVar(&opts.adultOnly, "adult-only", false, "Only blocklist adult anime")
+	flag.BoolVar(&opts.skipUnmappedSpecials, "skip-unmapped-specials", false, "Don't blocklist OVAs, web releases and other specials that aren't part of a TVDB series")
 	flag.Parse()
 
-	for _, f := range []string{".env", filepath.Join(exe, ".env")} {
-		if err := godotenv.Load(f); err != nil && !errors.Is(err, fs.ErrNotExist) {
-			log.Fatalf("%s: %v", f, err)
+	switch *color {
+	case "auto":
+		noColor := os.Getenv("NO_COLOR") != ""
+		console.Color = !noColor && console.IsTerminal(os.Stdout)
+		if !noColor && console.IsTerminal(os.Stderr) {
+			log.SetOutput(console.ErrorWriter(os.Stderr))
 		}
+	case "always":
+		console.Color = true
+		log.SetOutput(console.ErrorWriter(os.Stderr))
+	case "never":
+	default:
+		log.Fatalf("unknown -color %q", *color)
 	}
-	seerrHost := os.Getenv("SEERR_HOST")
-	seerrApiKey := os.Getenv("SEERR_API_KEY")
-	seerrUserId, err := strconv.Atoi(os.Getenv("SEERR_USER_ID"))
-	if seerrHost == "" || seerrApiKey == "" || err != nil {
-		log.Fatal("$SEERR_HOST/$SEERR_API_KEY/$SEERR_USER_ID are required")
+
+	if *printVersion || flag.Arg(0) == "version" {
+		fmt.Print(versionString())
+		return
+	}
+	if flag.Arg(0) == "completion" {
+		if err = writeCompletion(os.Stdout, flag.Arg(1), flag.CommandLine); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
 
-	seerrBlocklistClient, err := seerrApi.NewClient(seerrHost, seerrApiKey, "blocklist")
-	if err != nil {
+	if opts.popularityMetric != "votes" && opts.popularityMetric != "popularity" {
+		log.Fatalf("unknown -popularity-metric %q", opts.popularityMetric)
+	}
+	if opts.adultOnly && !opts.includeAdult {
+		log.Fatal("-adult-only and -include-adult=false are mutually exclusive")
+	}
+	opts.location = time.Local
+	if *timezone != "" {
+		if opts.location, err = time.LoadLocation(*timezone); err != nil {
+			log.Fatalf("-timezone: %v", err)
+		}
+	}
+	if opts.syncAt != "" {
+		if _, err = time.Parse("15:04", opts.syncAt); err != nil {
+			log.Fatalf("-sync-at must be HH:MM: %v", err)
+		}
+	}
+	if opts.scheduleJitter < 0 {
+		log.Fatal("-schedule-jitter can't be negative")
+	}
+	if opts.rateLimit < 0 || math.IsNaN(opts.rateLimit) {
+		log.Fatal("-rate-limit can't be negative")
+	}
+	if opts.daemon && opts.interval <= 0 {
+		log.Fatal("-interval must be positive")
+	}
+	if opts.emitScript != "" && (opts.target != "seerr" || opts.skipTitles || opts.blocklistKeyword || opts.overrideSonarrId >= 0 || opts.restrictUserIds != "" || opts.cleanWatchlists || opts.sonarr || opts.radarr || opts.daemon || opts.watch || opts.interactive) {
+		log.Fatal("-emit-script only covers Seerr's blocklist, so can't be combined with options making other changes")
+	}
+	if opts.emitScript != "" && opts.verbose {
+		log.Fatal("-emit-script writes to stdout, so can't be combined with -verbose")
+	}
+	if opts.userAllowlists != "" && opts.target != "seerr" {
+		log.Fatal("-user-allowlists needs Seerr's users, so only works with -target seerr")
+	}
+	if opts.skipAvailable && opts.target != "seerr" {
+		log.Fatal("-skip-available needs Seerr's library, so only works with -target seerr")
+	}
+	if (opts.daemon || opts.watch) && opts.mappingFile == "-" {
+		log.Fatal("-mapping-file - can't be read by every sync of -daemon or -watch")
+	}
+	if opts.mappingCache.force && opts.mappingCache.offline {
+		log.Fatal("-force-refresh and -offline are mutually exclusive")
+	}
+
+	if *proxy != "" {
+		proxyUrl, err := url.Parse(*proxy)
+		if err != nil {
+			log.Fatalf("-proxy: %v", err)
+		}
+		switch proxyUrl.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			log.Fatalf("-proxy: unsupported scheme %q", proxyUrl.Scheme)
+		}
+		restApi.SetProxy(proxyUrl)
+		http.DefaultTransport.(*http.Transport).Proxy = http.ProxyURL(proxyUrl)
+	}
+
+	restApi.UserAgent = userAgent()
+	restApi.SetTimeouts(timeouts)
+	restApi.SetHTTP2(*http2)
+	restApi.SetMaxIdleConnsPerHost(*maxIdleConnsPerHost)
+	if err = restApi.SetIPVersion(*ipVersion); err != nil {
+		log.Fatalf("-ip-version: %v", err)
+	}
+	if *dnsServer != "" {
+		restApi.SetDNSServer(*dnsServer)
+	}
+	if tlsConfig, err := tlsOpts.config(); err != nil {
 		log.Fatal(err)
+	} else if tlsConfig != nil {
+		restApi.SetTLSConfig(tlsConfig)
+		// Like -proxy, also for downloadClient's downloads of the mapping, ID lists and releases
+		http.DefaultTransport.(*http.Transport).TLSClientConfig = tlsConfig.Clone()
 	}
 
-	blocklisted, err := getAlreadyBlocklisted(seerrBlocklistClient)
-	if err != nil {
+	// Before loading the .env files, for -daemon to run syncs that load them afresh
+	environ := os.Environ()
+	if err = loadEnv(opts.envFile, exe); err != nil {
 		log.Fatal(err)
 	}
+	for header := range strings.Lines(os.Getenv("SEERR_HEADERS")) {
+		if header = strings.TrimSpace(header); header == "" {
+			continue
+		}
+		if err = addHeader(seerrApi.ExtraHeader, header); err != nil {
+			log.Fatalf("$SEERR_HEADERS: %v", err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	// A second signal kills the process as usual
+	context.AfterFunc(ctx, stop)
+	if os.Getenv(daemonChildEnv) != "" {
+		stopWithDaemon(stop)
+	}
 
-	fdp, err := fetchAndParseAnimeList(cacheDir)
+	// The flags without the command and its arguments
+	globalArgs := os.Args[1 : len(os.Args)-flag.NArg()]
+	if flag.Arg(0) == "service" {
+		runService(ctx, &opts, flag.Args()[1:], globalArgs, environ, exe)
+		return
+	}
+	if (opts.daemon || opts.watch) && os.Getenv(daemonChildEnv) == "" && flag.Arg(0) == "" {
+		runDaemon(ctx, &opts, globalArgs, environ, exe)
+		return
+	}
+	// With -daemon, by each sync rather than the daemon, which does little itself
+	if opts.pprof != "" {
+		if err = startPprof(opts.pprof); err != nil {
+			log.Fatalf("-pprof: %v", err)
+		}
+	}
+
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+
+	// These don't touch the cache, so can run alongside syncs against it
+	switch flag.Arg(0) {
+	case "serve-mock":
+		runServeMock(ctx, flag.Args()[1:])
+		return
+	case "self-update":
+		runSelfUpdate(ctx, flag.Args()[1:])
+		return
+	}
+
+	if err = os.MkdirAll(opts.cacheDir, 0o755); err != nil {
+		log.Fatal(err)
+	}
+	lock, err := lockCacheDir(opts.cacheDir)
 	if err != nil {
 		log.Fatal(err)
 	}
+	defer lock.Close()
+
+	switch flag.Arg(0) {
+	case "":
+	case "browse":
+		runBrowse(ctx, &opts, flag.Args()[1:])
+		return
+	case "export":
+		runExport(ctx, &opts, flag.Args()[1:])
+		return
+	case "list":
+		runList(ctx, &opts, flag.Args()[1:])
+		return
+	case "repair":
+		runRepair(ctx, &opts, flag.Args()[1:])
+		return
+	case "rollback":
+		runRollback(ctx, &opts, flag.Args()[1:])
+		return
+	case "simkl-login":
+		runSimklLogin(ctx, &opts)
+		return
+	case "stats":
+		runStats(ctx, &opts, flag.Args()[1:])
+		return
+	case "trakt-login":
+		runTraktLogin(ctx, &opts)
+		return
+	default:
+		log.Fatalf("unknown command %q", flag.Arg(0))
+	}
 
-	blocklistReqBody := &seerrApi.PostBlocklistJSONRequestBody{
-		MediaType: seerrApi.MediaTypeTv,
-		User:      seerrUserId,
+	buildAllowlist(ctx, &opts)
+
+	var fdp []AnimeList.Anime
+	switch opts.target {
+	case "seerr":
+		fdp = runSeerr(ctx, &opts)
+	case "ombi":
+		fdp = runOmbi(ctx, &opts)
+	default:
+		log.Fatalf("unknown target %q", opts.target)
 	}
 
-	for _, p := range fdp {
-		tmdbId := p.Tmdbtv
-		if tmdbId == 0 {
-			continue
+	if opts.sonarr {
+		sonarrHost := os.Getenv("SONARR_HOST")
+		sonarrApiKey := os.Getenv("SONARR_API_KEY")
+		if sonarrHost == "" || sonarrApiKey == "" {
+			log.Fatal("$SONARR_HOST/$SONARR_API_KEY are required")
 		}
 
-		if _, ok := blocklisted[tmdbId]; !ok {
-			if verbose {
-				fmt.Printf("Adding %s (%v)\n", p.Name, tmdbId)
-			}
-			blocklistReqBody.TmdbId = tmdbId
-			blocklistReqBody.Title = p.Name
-		retry:
-			err = seerrBlocklistClient.Post("", nil, blocklistReqBody, nil)
-			if err != nil {
-				_, ok = blocklisted[tmdbId]
-				if err, ok2 := errors.AsType[*seerrApi.HTTPError](err); !ok && ok2 && err.StatusCode == http.StatusPreconditionFailed {
-					// On TMDB, IDs can be shared between shows and movies; Seerr doesn't differentiate, so delete the
-					// existing movie and attempt to re-add the anime series
-					blocklisted[tmdbId] = struct{}{}
-					if seerrBlocklistClient.Delete(fmt.Sprintf("/%d", tmdbId), nil, nil) == nil {
-						goto retry
-					}
-					continue
-				}
-				log.Printf("Error adding %s (%v) to blocklist: %v", p.Name, tmdbId, err)
-			} else {
-				blocklisted[tmdbId] = struct{}{}
-			}
+		sonarrExclusionClient, err := arrApi.NewClient(sonarrHost, sonarrApiKey, "importlistexclusion")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if err = addSonarrExclusions(ctx, sonarrExclusionClient, fdp, opts.verbose); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if opts.radarr {
+		radarrHost := os.Getenv("RADARR_HOST")
+		radarrApiKey := os.Getenv("RADARR_API_KEY")
+		if radarrHost == "" || radarrApiKey == "" {
+			log.Fatal("$RADARR_HOST/$RADARR_API_KEY are required")
+		}
+
+		radarrExclusionClient, err := arrApi.NewClient(radarrHost, radarrApiKey, "exclusions")
+		if err != nil {
+			log.Fatal(err)
 		}
+
+		if err = addRadarrExclusions(ctx, radarrExclusionClient, fdp, opts.verbose); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		log.Fatalf("Stopped: %v", context.Cause(ctx))
 	}
 }
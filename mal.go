@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"anime-to-seerr-blocklist/internal/console"
+	"anime-to-seerr-blocklist/internal/mal"
+)
+
+// addMalLists allowlists the anime a MyAnimeList user is watching or planning to watch
+func addMalLists(ctx context.Context, malUserClient *malApi.Client, userName string, anidbIds map[int]int, allowlistAnidb map[int]struct{}, verbose bool) error {
+	const limit = 1000
+
+	values := url.Values{
+		"status": []string{""},
+		"limit":  []string{strconv.Itoa(limit)},
+		"offset": []string{""},
+	}
+
+	for _, status := range []string{malApi.StatusPlanToWatch, malApi.StatusWatching} {
+		values["status"][0] = status
+
+		for offset := 0; ; offset += limit {
+			var resp malApi.GetUserAnimeListResponse
+			values["offset"][0] = strconv.Itoa(offset)
+
+			if err := malUserClient.Get(ctx, fmt.Sprintf("/%s/animelist", url.PathEscape(userName)), values, &resp); err != nil {
+				return err
+			}
+
+			for _, entry := range resp.Data {
+				if anidbId, ok := anidbIds[entry.Node.Id]; ok {
+					if verbose {
+						console.Skipped("Allowlisting %s (AniDB %v) from MyAnimeList\n", entry.Node.Title, anidbId)
+					}
+					allowlistAnidb[anidbId] = struct{}{}
+				}
+			}
+
+			if resp.Paging.Next == "" || len(resp.Data) == 0 {
+				break
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	"anime-to-seerr-blocklist/internal/anime-list"
+)
+
+// jsonString decodes either a JSON string or a number, as exports differ in which they use for IDs
+type jsonString string
+
+func (s *jsonString) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		return json.Unmarshal(b, (*string)(s))
+	}
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	*s = jsonString(b)
+	return nil
+}
+
+// animeListJSONEntry is an entry of the mapping exported as JSON, with the attributes of the XML as its keys
+type animeListJSONEntry struct {
+	Anidbid    int        `json:"anidbid"`
+	Tvdbid     jsonString `json:"tvdbid"`
+	Tmdbid     jsonString `json:"tmdbid"`
+	Tmdbtv     int        `json:"tmdbtv"`
+	Tmdbseason jsonString `json:"tmdbseason"`
+	Name       string     `json:"name"`
+}
+
+// parseAnimeListJSON decodes the mapping exported as a JSON array one entry at a time, keeping only those keep accepts
+func parseAnimeListJSON(r io.Reader, keep func(p *AnimeList.Anime) bool) ([]AnimeList.Anime, error) {
+	var fdp []AnimeList.Anime
+
+	d := json.NewDecoder(r)
+	tok, err := d.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok != json.Delim('[') {
+		return nil, fmt.Errorf("expected the mapping to be a JSON array, got %v", tok)
+	}
+
+	for d.More() {
+		var e animeListJSONEntry
+		if err = d.Decode(&e); err != nil {
+			return nil, err
+		}
+
+		p := AnimeList.Anime{
+			Anidbid:    e.Anidbid,
+			Tvdbid:     string(e.Tvdbid),
+			Tmdbid:     string(e.Tmdbid),
+			Tmdbtv:     e.Tmdbtv,
+			Tmdbseason: string(e.Tmdbseason),
+			Name:       e.Name,
+		}
+		if keep(&p) {
+			fdp = append(fdp, p)
+		}
+	}
+	if _, err = d.Token(); err != nil {
+		return nil, err
+	}
+
+	return slices.Clip(fdp), nil
+}
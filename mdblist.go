@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"anime-to-seerr-blocklist/internal/anime-list"
+	"anime-to-seerr-blocklist/internal/console"
+	"anime-to-seerr-blocklist/internal/mdblist"
+)
+
+// fetchMdblistList returns the shows on an MDBList list given as user/list or by its ID. Its movies are left out, as
+// only series are blocklisted
+func fetchMdblistList(ctx context.Context, mdblistListsClient *mdblistApi.Client, list string) ([]mdblistApi.ListItem, error) {
+	const limit = 1000
+
+	var endpoint string
+	if _, err := strconv.Atoi(list); err == nil {
+		endpoint = "/" + list + "/items"
+	} else if user, slug, ok := strings.Cut(list, "/"); ok {
+		endpoint = fmt.Sprintf("/%s/%s/items", url.PathEscape(user), url.PathEscape(slug))
+	} else {
+		return nil, fmt.Errorf("MDBList list %q isn't in the form user/list or an ID", list)
+	}
+
+	values := url.Values{
+		"limit":  []string{strconv.Itoa(limit)},
+		"offset": []string{""},
+	}
+
+	var shows []mdblistApi.ListItem
+	for offset := 0; ; offset += limit {
+		var resp mdblistApi.GetListItemsResponse
+		values["offset"][0] = strconv.Itoa(offset)
+
+		if err := mdblistListsClient.Get(ctx, endpoint, values, &resp); err != nil {
+			return nil, err
+		}
+		shows = append(shows, resp.Shows...)
+
+		if len(resp.Movies)+len(resp.Shows) < limit {
+			return shows, nil
+		}
+	}
+}
+
+// addMdblistLists allowlists the shows on $MDBLIST_ALLOWLIST with -allowlist-mdblist and blocks those on
+// $MDBLIST_BLOCKLIST with -blocklist-mdblist in addition to the mapping
+func addMdblistLists(ctx context.Context, opts *options) {
+	var allowlist, blocklist string
+	if opts.allowlistMdblist {
+		if allowlist = os.Getenv("MDBLIST_ALLOWLIST"); allowlist == "" {
+			log.Fatal("$MDBLIST_ALLOWLIST is required")
+		}
+	}
+	if opts.blocklistMdblist {
+		if blocklist = os.Getenv("MDBLIST_BLOCKLIST"); blocklist == "" {
+			log.Fatal("$MDBLIST_BLOCKLIST is required")
+		}
+	}
+
+	apiKey := os.Getenv("MDBLIST_API_KEY")
+	if apiKey == "" {
+		log.Fatal("$MDBLIST_API_KEY is required")
+	}
+	mdblistListsClient, err := mdblistApi.NewClient(apiKey, "lists")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if allowlist != "" {
+		items, err := fetchMdblistList(ctx, mdblistListsClient, allowlist)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, item := range items {
+			if item.Id != 0 {
+				if opts.verbose {
+					console.Skipped("Allowlisting %s (%v) from MDBList\n", item.Title, item.Id)
+				}
+				opts.allowlist[item.Id] = struct{}{}
+			}
+		}
+	}
+
+	if blocklist != "" {
+		items, err := fetchMdblistList(ctx, mdblistListsClient, blocklist)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, item := range items {
+			if item.Id != 0 {
+				opts.extraBlocklist = append(opts.extraBlocklist, AnimeList.Anime{Tmdbtv: item.Id, Name: item.Title})
+			}
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"net/url"
+
+	"anime-to-seerr-blocklist/internal/console"
+	"anime-to-seerr-blocklist/internal/seerr"
+)
+
+// allowlistAvailable allowlists the series already available in Seerr, as blocklisting what's in the library only
+// confuses users browsing it
+func allowlistAvailable(ctx context.Context, seerrMediaClient *seerrApi.Client, allowlist map[int]struct{}, verbose bool) error {
+	values := url.Values{"filter": []string{seerrApi.GetMediaParamsFilterAvailable}}
+	return seerrApi.GetPages(ctx, seerrMediaClient, values, func(results []seerrApi.MediaInfo) {
+		for _, media := range results {
+			if media.Status != seerrApi.MediaInfoStatusAvailable || media.MediaType != seerrApi.MediaTypeTv || media.TmdbId == 0 {
+				continue
+			}
+			if verbose {
+				console.Skipped("Allowlisting %v as it's available in Seerr\n", media.TmdbId)
+			}
+			allowlist[media.TmdbId] = struct{}{}
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"log"
+
+	"anime-to-seerr-blocklist/internal/anime-list"
+	"anime-to-seerr-blocklist/internal/console"
+)
+
+type mergedEntry struct {
+	// index of the entry in fdp, or -1 if it was excluded
+	index  int
+	source string
+}
+
+// mappingMerge combines the entries of the sources of -source into one mapping. Where the sources map the same AniDB
+// entry to different TMDB series, the source merged first wins and the conflict is reported, while an entry a source
+// left unmapped is filled in by the sources after it. Entries without an AniDB ID are merged as they are
+type mappingMerge struct {
+	fdp       []AnimeList.Anime
+	byAnidbId map[int]mergedEntry
+	excluded  map[int]struct{}
+	conflicts int
+	verbose   bool
+}
+
+func newMappingMerge(verbose bool) *mappingMerge {
+	return &mappingMerge{
+		byAnidbId: make(map[int]mergedEntry),
+		excluded:  make(map[int]struct{}),
+		verbose:   verbose,
+	}
+}
+
+// exclude keeps the AniDB entry anidbId out of the merged mapping whatever the other sources say about it
+func (m *mappingMerge) exclude(anidbId int, source string) {
+	if merged, ok := m.byAnidbId[anidbId]; ok && merged.index >= 0 {
+		m.excluded[merged.index] = struct{}{}
+	}
+	m.byAnidbId[anidbId] = mergedEntry{index: -1, source: source}
+}
+
+func (m *mappingMerge) add(source string, fdp []AnimeList.Anime) {
+	for _, p := range fdp {
+		if p.Anidbid == 0 {
+			m.fdp = append(m.fdp, p)
+			continue
+		}
+
+		merged, ok := m.byAnidbId[p.Anidbid]
+		if !ok {
+			m.byAnidbId[p.Anidbid] = mergedEntry{index: len(m.fdp), source: source}
+			m.fdp = append(m.fdp, p)
+			continue
+		}
+		if merged.index < 0 {
+			continue
+		}
+
+		existing := &m.fdp[merged.index]
+		if existing.Name == "" {
+			existing.Name = p.Name
+		}
+		switch {
+		case p.Tmdbtv == 0 || p.Tmdbtv == existing.Tmdbtv:
+		case existing.Tmdbtv == 0:
+			existing.Tmdbtv, existing.Tmdbseason = p.Tmdbtv, p.Tmdbseason
+		default:
+			m.conflicts++
+			if m.verbose {
+				console.Skipped("Conflicting mapping for %s (AniDB %v): %s maps it to %v, %s to %v\n", existing.Name, p.Anidbid, merged.source, existing.Tmdbtv, source, p.Tmdbtv)
+			}
+		}
+	}
+}
+
+// mapping returns the merged mapping, reporting how many entries the sources disagreed on
+func (m *mappingMerge) mapping() []AnimeList.Anime {
+	if m.conflicts > 0 {
+		log.Printf("The sources map %d AniDB entries to different TMDB series, the first source's were used", m.conflicts)
+	}
+	if len(m.excluded) == 0 {
+		return m.fdp
+	}
+
+	fdp := make([]AnimeList.Anime, 0, len(m.fdp)-len(m.excluded))
+	for i, p := range m.fdp {
+		if _, ok := m.excluded[i]; !ok {
+			fdp = append(fdp, p)
+		}
+	}
+	return fdp
+}
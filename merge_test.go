@@ -0,0 +1,110 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"anime-to-seerr-blocklist/internal/anime-list"
+)
+
+func TestMappingMerge(t *testing.T) {
+	type source struct {
+		name string
+		fdp  []AnimeList.Anime
+		// excluded AniDB IDs, as the source drops them
+		excluded []int
+	}
+	tests := []struct {
+		name          string
+		sources       []source
+		want          []AnimeList.Anime
+		wantConflicts int
+	}{
+		{
+			name: "disjoint",
+			sources: []source{
+				{name: "a", fdp: []AnimeList.Anime{{Anidbid: 1, Tmdbtv: 10}}},
+				{name: "b", fdp: []AnimeList.Anime{{Anidbid: 2, Tmdbtv: 20}}},
+			},
+			want: []AnimeList.Anime{{Anidbid: 1, Tmdbtv: 10}, {Anidbid: 2, Tmdbtv: 20}},
+		},
+		{
+			name: "agreeing",
+			sources: []source{
+				{name: "a", fdp: []AnimeList.Anime{{Anidbid: 1, Tmdbtv: 10, Tmdbseason: "1"}}},
+				{name: "b", fdp: []AnimeList.Anime{{Anidbid: 1, Tmdbtv: 10, Tmdbseason: "2"}}},
+			},
+			want: []AnimeList.Anime{{Anidbid: 1, Tmdbtv: 10, Tmdbseason: "1"}},
+		},
+		{
+			name: "first source wins conflicts",
+			sources: []source{
+				{name: "a", fdp: []AnimeList.Anime{{Anidbid: 1, Tmdbtv: 10}, {Anidbid: 2, Tmdbtv: 20}}},
+				{name: "b", fdp: []AnimeList.Anime{{Anidbid: 1, Tmdbtv: 11}, {Anidbid: 2, Tmdbtv: 21}}},
+			},
+			want:          []AnimeList.Anime{{Anidbid: 1, Tmdbtv: 10}, {Anidbid: 2, Tmdbtv: 20}},
+			wantConflicts: 2,
+		},
+		{
+			name: "unmapped filled in by later source",
+			sources: []source{
+				{name: "a", fdp: []AnimeList.Anime{{Anidbid: 1}}},
+				{name: "b", fdp: []AnimeList.Anime{{Anidbid: 1, Tmdbtv: 10, Tmdbseason: "2", Name: "Show"}}},
+			},
+			want: []AnimeList.Anime{{Anidbid: 1, Tmdbtv: 10, Tmdbseason: "2", Name: "Show"}},
+		},
+		{
+			name: "unmapped later source keeps mapping",
+			sources: []source{
+				{name: "a", fdp: []AnimeList.Anime{{Anidbid: 1, Tmdbtv: 10, Name: "Show"}}},
+				{name: "b", fdp: []AnimeList.Anime{{Anidbid: 1, Name: "Other name"}}},
+			},
+			want: []AnimeList.Anime{{Anidbid: 1, Tmdbtv: 10, Name: "Show"}},
+		},
+		{
+			name: "no AniDB ID merged as is",
+			sources: []source{
+				{name: "a", fdp: []AnimeList.Anime{{Tmdbtv: 10}}},
+				{name: "b", fdp: []AnimeList.Anime{{Tmdbtv: 10}}},
+			},
+			want: []AnimeList.Anime{{Tmdbtv: 10}, {Tmdbtv: 10}},
+		},
+		{
+			name: "excluded after being added",
+			sources: []source{
+				{name: "a", fdp: []AnimeList.Anime{{Anidbid: 1, Tmdbtv: 10}, {Anidbid: 2, Tmdbtv: 20}}},
+				{name: "b", excluded: []int{1}},
+			},
+			want: []AnimeList.Anime{{Anidbid: 2, Tmdbtv: 20}},
+		},
+		{
+			name: "excluded before being added",
+			sources: []source{
+				{name: "a", excluded: []int{1}},
+				{name: "b", fdp: []AnimeList.Anime{{Anidbid: 1, Tmdbtv: 10}, {Anidbid: 2, Tmdbtv: 20}}},
+			},
+			want: []AnimeList.Anime{{Anidbid: 2, Tmdbtv: 20}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMappingMerge(false)
+			for _, s := range tt.sources {
+				for _, anidbId := range s.excluded {
+					m.exclude(anidbId, s.name)
+				}
+				m.add(s.name, s.fdp)
+			}
+
+			if got := m.mapping(); !slices.EqualFunc(got, tt.want, func(a, b AnimeList.Anime) bool {
+				return a.Anidbid == b.Anidbid && a.Tmdbtv == b.Tmdbtv && a.Tmdbseason == b.Tmdbseason && a.Name == b.Name
+			}) {
+				t.Errorf("mapping() = %+v, want %+v", got, tt.want)
+			}
+			if m.conflicts != tt.wantConflicts {
+				t.Errorf("conflicts = %d, want %d", m.conflicts, tt.wantConflicts)
+			}
+		})
+	}
+}
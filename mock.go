@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"anime-to-seerr-blocklist/internal/seerr"
+)
+
+type mockBlocklistEntry struct {
+	TmdbId    int                `json:"tmdbId"`
+	MediaType seerrApi.MediaType `json:"mediaType"`
+	Title     string             `json:"title"`
+	CreatedAt time.Time          `json:"createdAt"`
+	User      seerrApi.User      `json:"user"`
+}
+
+// mockSeerr is an in-memory stand-in for Seerr's blocklist API, for trying out configurations and new features
+// without touching a real instance
+type mockSeerr struct {
+	apiKey  string
+	maxTake int
+	bulk    bool
+
+	mu      sync.Mutex
+	entries []*mockBlocklistEntry
+	byId    map[int]*mockBlocklistEntry
+	// Series available in the library
+	available []int
+
+	// Token bucket limiting requests to rate per second, 0 for no limit
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+var mockUser = seerrApi.User{Id: 1, Username: "admin", Email: "admin@example.com"}
+
+func (m *mockSeerr) add(entry *mockBlocklistEntry) {
+	m.entries = append(m.entries, entry)
+	m.byId[entry.TmdbId] = entry
+}
+
+// allow reports whether a request may proceed under the rate limit
+func (m *mockSeerr) allow() bool {
+	if m.rate <= 0 {
+		return true
+	}
+
+	now := time.Now()
+	m.tokens = math.Min(m.burst, m.tokens+now.Sub(m.last).Seconds()*m.rate)
+	m.last = now
+	if m.tokens < 1 {
+		return false
+	}
+	m.tokens--
+	return true
+}
+
+func (m *mockSeerr) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if r.Header.Get("X-Api-Key") != m.apiKey {
+		mockError(w, http.StatusForbidden, "You do not have permission to access this endpoint")
+		return
+	}
+	if !m.allow() {
+		w.Header().Set("Retry-After", "1")
+		mockError(w, http.StatusTooManyRequests, "Too many requests")
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/status", m.status)
+	mux.HandleFunc("GET /api/v1/user", m.users)
+	mux.HandleFunc("GET /api/v1/media", m.media)
+	mux.HandleFunc("GET /api/v1/blocklist", m.list)
+	mux.HandleFunc("POST /api/v1/blocklist", m.create)
+	if m.bulk {
+		mux.HandleFunc("POST /api/v1/blocklist/bulk", m.createBulk)
+	}
+	mux.HandleFunc("GET /api/v1/blocklist/{tmdbId}", m.get)
+	mux.HandleFunc("DELETE /api/v1/blocklist/{tmdbId}", m.remove)
+	mux.ServeHTTP(w, r)
+}
+
+func (m *mockSeerr) status(w http.ResponseWriter, _ *http.Request) {
+	mockJSON(w, http.StatusOK, map[string]string{"version": "mock"})
+}
+
+func (m *mockSeerr) users(w http.ResponseWriter, _ *http.Request) {
+	mockJSON(w, http.StatusOK, map[string]any{
+		"pageInfo": seerrApi.PageInfo{Pages: 1, PageSize: 1, Results: 1, Page: 1},
+		"results":  []seerrApi.User{mockUser},
+	})
+}
+
+// media lists the available series, whatever the filter, all on one page
+func (m *mockSeerr) media(w http.ResponseWriter, _ *http.Request) {
+	results := make([]seerrApi.MediaInfo, 0, len(m.available))
+	for _, tmdbId := range m.available {
+		results = append(results, seerrApi.MediaInfo{MediaType: seerrApi.MediaTypeTv, Status: seerrApi.MediaInfoStatusAvailable, TmdbId: tmdbId})
+	}
+	mockJSON(w, http.StatusOK, map[string]any{
+		"pageInfo": seerrApi.PageInfo{Pages: 1, PageSize: len(results), Results: len(results), Page: 1},
+		"results":  results,
+	})
+}
+
+func (m *mockSeerr) list(w http.ResponseWriter, r *http.Request) {
+	take, err := strconv.Atoi(r.URL.Query().Get("take"))
+	if err != nil || take <= 0 {
+		take = 25
+	}
+	if m.maxTake > 0 && take > m.maxTake {
+		take = m.maxTake
+	}
+	skip, _ := strconv.Atoi(r.URL.Query().Get("skip"))
+	skip = max(skip, 0)
+
+	// Newest first, like Seerr
+	results := make([]*mockBlocklistEntry, 0, take)
+	for i := len(m.entries) - 1 - skip; i >= 0 && len(results) < take; i-- {
+		results = append(results, m.entries[i])
+	}
+
+	mockJSON(w, http.StatusOK, map[string]any{
+		"pageInfo": seerrApi.PageInfo{
+			Pages:    (len(m.entries) + take - 1) / take,
+			PageSize: take,
+			Results:  len(m.entries),
+			Page:     skip/take + 1,
+		},
+		"results": results,
+	})
+}
+
+func (m *mockSeerr) create(w http.ResponseWriter, r *http.Request) {
+	var body seerrApi.PostBlocklistJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.TmdbId == 0 {
+		mockError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	// Seerr only allows one entry per TMDB ID, whether a series or a movie
+	if _, ok := m.byId[body.TmdbId]; ok {
+		mockError(w, http.StatusPreconditionFailed, "Item already blocklisted")
+		return
+	}
+
+	m.add(&mockBlocklistEntry{
+		TmdbId:    body.TmdbId,
+		MediaType: body.MediaType,
+		Title:     body.Title,
+		CreatedAt: time.Now(),
+		User:      seerrApi.User{Id: body.User},
+	})
+	w.WriteHeader(http.StatusCreated)
+}
+
+// createBulk adds the entries of a batch, refusing all of them if one is already blocklisted
+func (m *mockSeerr) createBulk(w http.ResponseWriter, r *http.Request) {
+	var body seerrApi.PostBlocklistBulkJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		mockError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	for _, item := range body.Items {
+		if item.TmdbId == 0 {
+			mockError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if _, ok := m.byId[item.TmdbId]; ok {
+			mockError(w, http.StatusPreconditionFailed, "Item already blocklisted")
+			return
+		}
+	}
+
+	for _, item := range body.Items {
+		m.add(&mockBlocklistEntry{
+			TmdbId:    item.TmdbId,
+			MediaType: item.MediaType,
+			Title:     item.Title,
+			CreatedAt: time.Now(),
+			User:      seerrApi.User{Id: item.User},
+		})
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (m *mockSeerr) get(w http.ResponseWriter, r *http.Request) {
+	tmdbId, _ := strconv.Atoi(r.PathValue("tmdbId"))
+	entry, ok := m.byId[tmdbId]
+	if !ok {
+		mockError(w, http.StatusNotFound, "Item not found")
+		return
+	}
+	mockJSON(w, http.StatusOK, entry)
+}
+
+func (m *mockSeerr) remove(w http.ResponseWriter, r *http.Request) {
+	tmdbId, _ := strconv.Atoi(r.PathValue("tmdbId"))
+	entry, ok := m.byId[tmdbId]
+	if !ok {
+		mockError(w, http.StatusNotFound, "Item not found")
+		return
+	}
+
+	delete(m.byId, tmdbId)
+	for i, e := range m.entries {
+		if e == entry {
+			m.entries = append(m.entries[:i], m.entries[i+1:]...)
+			break
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func mockJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func mockError(w http.ResponseWriter, status int, message string) {
+	mockJSON(w, status, map[string]string{"message": message})
+}
+
+func runServeMock(ctx context.Context, args []string) {
+	var listen, seedMovies, seedAvailable string
+	m := &mockSeerr{byId: make(map[int]*mockBlocklistEntry)}
+
+	fs := flag.NewFlagSet("serve-mock", flag.ExitOnError)
+	fs.StringVar(&listen, "listen", "localhost:5055", "Address to listen on")
+	fs.StringVar(&m.apiKey, "api-key", "mock", "API key to accept")
+	fs.IntVar(&m.maxTake, "max-take", 0, "Cap the page size requested with take, 0 for no cap")
+	fs.BoolVar(&m.bulk, "bulk", false, "Accept additions in batches at POST /api/v1/blocklist/bulk")
+	fs.Float64Var(&m.rate, "rate-limit", 0, "Requests per second to allow before responding with 429, 0 for no limit")
+	fs.Float64Var(&m.burst, "burst", 10, "Requests to allow at once under -rate-limit")
+	fs.StringVar(&seedMovies, "seed-movies", "", "Comma-separated TMDB IDs of movies to start the blocklist with, to exercise conflicts")
+	fs.StringVar(&seedAvailable, "seed-available", "", "Comma-separated TMDB IDs of series available in the library")
+	_ = fs.Parse(args)
+
+	ids, err := parseIds(seedMovies)
+	if err != nil {
+		log.Fatalf("-seed-movies: %v", err)
+	}
+	if m.available, err = parseIds(seedAvailable); err != nil {
+		log.Fatalf("-seed-available: %v", err)
+	}
+	for _, id := range ids {
+		m.add(&mockBlocklistEntry{TmdbId: id, MediaType: seerrApi.MediaTypeMovie, CreatedAt: time.Now(), User: mockUser})
+	}
+	m.tokens = m.burst
+	m.last = time.Now()
+
+	l, err := net.Listen("tcp", listen)
+	if err != nil {
+		log.Fatal(err)
+	}
+	srv := &http.Server{Handler: m}
+	context.AfterFunc(ctx, func() {
+		_ = srv.Shutdown(context.Background())
+	})
+
+	log.Printf("Mock Seerr listening on http://%s with API key %q", l.Addr(), m.apiKey)
+	if err = srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
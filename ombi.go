@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strconv"
+
+	"anime-to-seerr-blocklist/internal/anime-list"
+	"anime-to-seerr-blocklist/internal/console"
+	"anime-to-seerr-blocklist/internal/ombi"
+)
+
+// syncOmbi is the Ombi counterpart to blocklisting: Ombi has no per-title blocklist, so anime is hidden from discovery
+// through its excluded TMDB keywords and any pending series requests for mapped anime are denied
+func syncOmbi(ctx context.Context, ombiSettingsClient, ombiRequestClient *ombiApi.Client, fdp []AnimeList.Anime, verbose bool) error {
+	var settings ombiApi.TheMovieDbSettings
+	if err := ombiSettingsClient.Get(ctx, "/themoviedb", nil, &settings); err != nil {
+		return err
+	}
+
+	animeKeyword, _ := strconv.Atoi(animeKeywordId)
+	if !slices.Contains(settings.ExcludedKeywordIds, animeKeyword) {
+		if verbose {
+			console.Added("Adding keyword %s to excluded keywords\n", animeKeywordId)
+		}
+		settings.ExcludedKeywordIds = append(settings.ExcludedKeywordIds, animeKeyword)
+		if err := ombiSettingsClient.Post(ctx, "/themoviedb", nil, &settings, nil); err != nil {
+			return err
+		}
+	}
+
+	animeTmdbIds := animeTmdbIdSet(fdp)
+
+	var requests []ombiApi.TvRequest
+	if err := ombiRequestClient.Get(ctx, "/tv", nil, &requests); err != nil {
+		return err
+	}
+
+	for _, request := range requests {
+		if _, ok := animeTmdbIds[request.ExternalProviderId]; !ok {
+			continue
+		}
+
+		for _, child := range request.ChildRequests {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if child.Approved || child.Available || child.Denied {
+				continue
+			}
+
+			if verbose {
+				console.Removed("Denying request %d for %s (%v)\n", child.Id, request.Title, request.ExternalProviderId)
+			}
+			if err := ombiRequestClient.Put(ctx, "/tv/deny", nil, &ombiApi.DenyTvModel{Id: child.Id, Reason: "Anime"}, nil); err != nil {
+				log.Printf("Error denying request %d for %s (%v): %v", child.Id, request.Title, request.ExternalProviderId, err)
+			}
+		}
+	}
+
+	return nil
+}
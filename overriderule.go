@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"anime-to-seerr-blocklist/internal/console"
+	"anime-to-seerr-blocklist/internal/seerr"
+)
+
+// upsertOverrideRule makes Seerr route requests for anime to the given Sonarr server (and optionally profile, root
+// folder and tags) rather than blocking them. A rule matching the anime keyword on the same Sonarr server is updated
+// if one exists, otherwise a new rule is created
+func upsertOverrideRule(ctx context.Context, seerrOverrideRuleClient *seerrApi.Client, rule seerrApi.OverrideRule, verbose bool) error {
+	var rules []seerrApi.OverrideRule
+	if err := seerrOverrideRuleClient.Get(ctx, "", nil, &rules); err != nil {
+		return err
+	}
+
+	rule.Keywords = animeKeywordId
+	for _, existing := range rules {
+		if existing.SonarrServiceId == nil || *existing.SonarrServiceId != *rule.SonarrServiceId {
+			continue
+		}
+		if !slices.Contains(strings.Split(existing.Keywords, ","), animeKeywordId) {
+			continue
+		}
+
+		if existing.ProfileId == rule.ProfileId && existing.RootFolder == rule.RootFolder && existing.Tags == rule.Tags {
+			return nil
+		}
+
+		if verbose {
+			console.Added("Updating override rule %d\n", existing.Id)
+		}
+		existing.ProfileId, existing.RootFolder, existing.Tags = rule.ProfileId, rule.RootFolder, rule.Tags
+		return seerrOverrideRuleClient.Put(ctx, fmt.Sprintf("/%d", existing.Id), nil, &existing, nil)
+	}
+
+	if verbose {
+		console.Added("Creating override rule for Sonarr server %d\n", *rule.SonarrServiceId)
+	}
+	return seerrOverrideRuleClient.Post(ctx, "", nil, &rule, nil)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"anime-to-seerr-blocklist/internal/anime-list"
+	"anime-to-seerr-blocklist/internal/console"
+)
+
+// mappingOverride corrects the mapping of an AniDB entry, or adds it if the mapping lacks it
+type mappingOverride struct {
+	Anidbid    int    `json:"anidbid"`
+	Tmdbtv     int    `json:"tmdbtv,omitzero"`
+	Tmdbseason string `json:"tmdbseason,omitzero"`
+	Name       string `json:"name,omitzero"`
+	// Ignore drops the entry from the mapping instead
+	Ignore bool `json:"ignore,omitzero"`
+}
+
+// mappingOverrides are the local corrections of -mapping-overrides, applied on top of the mapping so that a wrong
+// mapping can be fixed without waiting for upstream to
+type mappingOverrides struct {
+	byAnidbId map[int]*mappingOverride
+	seen      map[int]struct{}
+	verbose   bool
+}
+
+func loadMappingOverrides(filename string, verbose bool) (*mappingOverrides, error) {
+	o := &mappingOverrides{
+		byAnidbId: make(map[int]*mappingOverride),
+		seen:      make(map[int]struct{}),
+		verbose:   verbose,
+	}
+	if filename == "" {
+		return o, nil
+	}
+
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var overrides []mappingOverride
+	if err = json.Unmarshal(b, &overrides); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", filename, err)
+	}
+
+	for i := range overrides {
+		override := &overrides[i]
+		switch {
+		case override.Anidbid <= 0:
+			return nil, fmt.Errorf("%s: override %d has no anidbid", filename, i+1)
+		case !override.Ignore && override.Tmdbtv <= 0:
+			return nil, fmt.Errorf("%s: override for AniDB %d has neither a tmdbtv nor ignore", filename, override.Anidbid)
+		}
+		if _, ok := o.byAnidbId[override.Anidbid]; ok {
+			return nil, fmt.Errorf("%s: AniDB %d is overridden more than once", filename, override.Anidbid)
+		}
+		o.byAnidbId[override.Anidbid] = override
+	}
+
+	return o, nil
+}
+
+// apply corrects p by its override, reporting whether p is still to be kept in the mapping
+func (o *mappingOverrides) apply(p *AnimeList.Anime) bool {
+	override, ok := o.byAnidbId[p.Anidbid]
+	if !ok {
+		return true
+	}
+	o.seen[p.Anidbid] = struct{}{}
+
+	if override.Ignore {
+		if o.verbose {
+			console.Skipped("Ignoring %s (AniDB %v) as overridden\n", p.Name, p.Anidbid)
+		}
+		return false
+	}
+
+	p.Tmdbtv = override.Tmdbtv
+	if override.Tmdbseason != "" {
+		p.Tmdbseason = override.Tmdbseason
+	}
+	if override.Name != "" {
+		p.Name = override.Name
+	}
+	return true
+}
+
+// additions returns the entries of the overrides that the mapping didn't have
+func (o *mappingOverrides) additions() []AnimeList.Anime {
+	var fdp []AnimeList.Anime
+	for anidbId, override := range o.byAnidbId {
+		if _, ok := o.seen[anidbId]; ok || override.Ignore {
+			continue
+		}
+		fdp = append(fdp, AnimeList.Anime{
+			Anidbid:    anidbId,
+			Tmdbtv:     override.Tmdbtv,
+			Tmdbseason: override.Tmdbseason,
+			Name:       override.Name,
+		})
+	}
+	return fdp
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"anime-to-seerr-blocklist/internal/anime-list"
+)
+
+func TestLoadMappingOverrides(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr bool
+	}{
+		{"empty", `[]`, false},
+		{"valid", `[{"anidbid": 1, "tmdbtv": 10, "tmdbseason": "2", "name": "Show"}, {"anidbid": 2, "ignore": true}]`, false},
+		{"not JSON", `anidbid=1`, true},
+		{"not a list", `{"anidbid": 1, "tmdbtv": 10}`, true},
+		{"no anidbid", `[{"tmdbtv": 10}]`, true},
+		{"negative anidbid", `[{"anidbid": -1, "tmdbtv": 10}]`, true},
+		{"neither tmdbtv nor ignore", `[{"anidbid": 1, "name": "Show"}]`, true},
+		{"duplicate", `[{"anidbid": 1, "tmdbtv": 10}, {"anidbid": 1, "ignore": true}]`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "overrides.json")
+			if err := os.WriteFile(filename, []byte(tt.json), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := loadMappingOverrides(filename, false); (err != nil) != tt.wantErr {
+				t.Errorf("loadMappingOverrides() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMappingOverridesApply(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "overrides.json")
+	overridesJson := `[
+		{"anidbid": 1, "tmdbtv": 10},
+		{"anidbid": 2, "tmdbtv": 20, "tmdbseason": "3", "name": "Renamed"},
+		{"anidbid": 3, "ignore": true},
+		{"anidbid": 4, "tmdbtv": 40, "name": "Added"},
+		{"anidbid": 5, "ignore": true}
+	]`
+	if err := os.WriteFile(filename, []byte(overridesJson), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	o, err := loadMappingOverrides(filename, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		in       AnimeList.Anime
+		want     AnimeList.Anime
+		wantKeep bool
+	}{
+		{AnimeList.Anime{Anidbid: 1, Tmdbtv: 11, Tmdbseason: "1", Name: "Show"}, AnimeList.Anime{Anidbid: 1, Tmdbtv: 10, Tmdbseason: "1", Name: "Show"}, true},
+		{AnimeList.Anime{Anidbid: 2, Tmdbtv: 21, Tmdbseason: "1", Name: "Show"}, AnimeList.Anime{Anidbid: 2, Tmdbtv: 20, Tmdbseason: "3", Name: "Renamed"}, true},
+		{AnimeList.Anime{Anidbid: 3, Tmdbtv: 30}, AnimeList.Anime{Anidbid: 3, Tmdbtv: 30}, false},
+		{AnimeList.Anime{Anidbid: 6, Tmdbtv: 60}, AnimeList.Anime{Anidbid: 6, Tmdbtv: 60}, true},
+	}
+	for _, tt := range tests {
+		p := tt.in
+		if keep := o.apply(&p); keep != tt.wantKeep || p.Tmdbtv != tt.want.Tmdbtv || p.Tmdbseason != tt.want.Tmdbseason || p.Name != tt.want.Name {
+			t.Errorf("apply(%+v) = %+v, %t, want %+v, %t", tt.in, p, keep, tt.want, tt.wantKeep)
+		}
+	}
+
+	// Only the override that no entry matched and that isn't ignoring one is left to add
+	additions := o.additions()
+	if want := []AnimeList.Anime{{Anidbid: 4, Tmdbtv: 40, Name: "Added"}}; !slices.EqualFunc(additions, want, func(a, b AnimeList.Anime) bool {
+		return a.Anidbid == b.Anidbid && a.Tmdbtv == b.Tmdbtv && a.Tmdbseason == b.Tmdbseason && a.Name == b.Name
+	}) {
+		t.Errorf("additions() = %+v, want %+v", additions, want)
+	}
+}
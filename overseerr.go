@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+
+	"anime-to-seerr-blocklist/internal/console"
+	"anime-to-seerr-blocklist/internal/seerr"
+)
+
+// declineAnimeRequests declines pending requests for anime, for servers like Overseerr that don't have a blocklist to
+// stop the requests from being made in the first place
+func declineAnimeRequests(ctx context.Context, seerrRequestClient *seerrApi.Client, animeTmdbIds map[int]struct{}, verbose bool) error {
+	values := url.Values{"filter": []string{seerrApi.GetRequestParamsFilterPending}}
+
+	var decline []int
+	err := seerrApi.GetPages(ctx, seerrRequestClient, values, func(results []seerrApi.MediaRequest) {
+		for _, request := range results {
+			if request.Status != seerrApi.MediaRequestStatusPending || request.Media.MediaType != seerrApi.MediaTypeTv {
+				continue
+			}
+			if _, ok := animeTmdbIds[request.Media.TmdbId]; ok {
+				decline = append(decline, request.Id)
+				if verbose {
+					console.Removed("Declining request %d for %v\n", request.Id, request.Media.TmdbId)
+				}
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, requestId := range decline {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err := seerrRequestClient.Post(ctx, fmt.Sprintf("/%d/decline", requestId), nil, nil, nil); err != nil {
+			log.Printf("Error declining request %d: %v", requestId, err)
+		}
+	}
+
+	return nil
+}
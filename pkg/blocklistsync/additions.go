@@ -0,0 +1,93 @@
+package blocklistSync
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"anime-to-seerr-blocklist/internal/atomicfile"
+)
+
+const additionsFile = "added.json"
+
+// Addition records a series a sync added to the blocklist itself. Seerr has nowhere on an entry to mark it as the
+// tool's, short of changing the title admins see, so the record is kept in the state directory instead
+type Addition struct {
+	Time  time.Time `json:"time,omitzero"`
+	Title string    `json:"title,omitzero"`
+	User  int       `json:"user,omitzero"`
+}
+
+// additions records the series syncs have added to the blocklist themselves, to tell them apart from entries made by
+// hand in Seerr
+type additions struct {
+	filename string
+	entries  map[int]Addition
+	changed  bool
+}
+
+func loadAdditions(stateDir string) (*additions, error) {
+	a := &additions{
+		filename: filepath.Join(stateDir, additionsFile),
+		entries:  make(map[int]Addition),
+	}
+
+	b, err := os.ReadFile(a.filename)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return a, nil
+		}
+		return nil, err
+	}
+
+	if err = json.Unmarshal(b, &a.entries); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Additions returns the series syncs with stateDir have added to the blocklist, by TMDB ID
+func Additions(stateDir string) (map[int]Addition, error) {
+	a, err := loadAdditions(stateDir)
+	if err != nil {
+		return nil, err
+	}
+	return a.entries, nil
+}
+
+func (a *additions) isAdded(tmdbId int) bool {
+	_, ok := a.entries[tmdbId]
+	return ok
+}
+
+func (a *additions) add(tmdbId int, title string, user int) {
+	a.entries[tmdbId] = Addition{Time: time.Now().UTC(), Title: title, User: user}
+	a.changed = true
+}
+
+func (a *additions) remove(tmdbId int) {
+	if a.isAdded(tmdbId) {
+		delete(a.entries, tmdbId)
+		a.changed = true
+	}
+}
+
+func (a *additions) save() error {
+	if !a.changed {
+		return nil
+	}
+
+	b, err := json.Marshal(a.entries)
+	if err != nil {
+		return err
+	}
+	if err = atomicFile.WriteFile(a.filename, b); err != nil {
+		return err
+	}
+
+	a.changed = false
+	return nil
+}
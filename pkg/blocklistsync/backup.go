@@ -0,0 +1,239 @@
+package blocklistSync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"anime-to-seerr-blocklist/internal/atomicfile"
+	"anime-to-seerr-blocklist/internal/console"
+	"anime-to-seerr-blocklist/internal/seerr"
+)
+
+const backupDir = "backups"
+
+// Older backups are deleted
+const keepBackups = 20
+
+// Backup is the blocklist as it was before a sync changed it
+type Backup struct {
+	Time time.Time `json:"time"`
+	// Partial is whether the blocklist could only be fetched in part, so entries may be missing
+	Partial bool                      `json:"partial"`
+	Entries []seerrApi.BlocklistEntry `json:"entries"`
+}
+
+// backupOnWrite saves a backup of the blocklist before the first change made through a BlocklistService, so a sync
+// that changes nothing leaves no backup behind
+type backupOnWrite struct {
+	BlocklistService
+
+	once   sync.Once
+	err    error
+	backup func() error
+}
+
+func (b *backupOnWrite) wait() error {
+	b.once.Do(func() {
+		b.err = b.backup()
+		if b.err != nil {
+			b.err = fmt.Errorf("failed to back up blocklist, not changing it: %w", b.err)
+			log.Print(b.err)
+		}
+	})
+	return b.err
+}
+
+func (b *backupOnWrite) Post(ctx context.Context, endpoint string, queryParams url.Values, reqBody any, respBody any) error {
+	if err := b.wait(); err != nil {
+		return err
+	}
+	return b.BlocklistService.Post(ctx, endpoint, queryParams, reqBody, respBody)
+}
+
+func (b *backupOnWrite) Delete(ctx context.Context, endpoint string, queryParams url.Values, reqBody any) error {
+	if err := b.wait(); err != nil {
+		return err
+	}
+	return b.BlocklistService.Delete(ctx, endpoint, queryParams, reqBody)
+}
+
+// saveBackup writes a timestamped backup of entries to stateDir
+func saveBackup(stateDir string, entries []seerrApi.BlocklistEntry, partial bool) error {
+	dir := filepath.Join(stateDir, backupDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	backup := Backup{Time: time.Now().UTC(), Partial: partial, Entries: entries}
+	b, err := json.Marshal(&backup)
+	if err != nil {
+		return err
+	}
+	if err = atomicFile.WriteFile(filepath.Join(dir, "blocklist-"+backup.Time.Format("20060102T150405.000Z")+".json"), b); err != nil {
+		return err
+	}
+
+	backups, err := Backups(stateDir)
+	if err != nil {
+		return err
+	}
+	for _, old := range backups[:max(len(backups)-keepBackups, 0)] {
+		if err = os.Remove(old); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Backups returns the paths of the backups in stateDir, oldest first
+func Backups(stateDir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(stateDir, backupDir, "blocklist-*.json"))
+	if err != nil {
+		return nil, err
+	}
+	// The timestamps sort lexically
+	slices.Sort(matches)
+	return matches, nil
+}
+
+// LoadBackup reads a backup by path, or by file name from stateDir's backups
+func LoadBackup(stateDir, name string) (*Backup, error) {
+	filename := name
+	if !strings.ContainsRune(name, filepath.Separator) {
+		filename = filepath.Join(stateDir, backupDir, name)
+	}
+
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("no backup %q", name)
+		}
+		return nil, err
+	}
+
+	var backup Backup
+	if err = json.Unmarshal(b, &backup); err != nil {
+		return nil, err
+	}
+	return &backup, nil
+}
+
+// RollbackReport summarises a rollback
+type RollbackReport struct {
+	Restored int
+	Removed  int
+	// Kept counts the entries added since that weren't removed as syncs didn't add them
+	Kept   int
+	Failed int
+}
+
+// Rollback restores the blocklist of cfg's Seerr instance to backup, re-adding the entries removed since and removing
+// those added since. Entries aren't removed if the backup is partial, as they may be missing from it rather than new,
+// nor unless cfg.Force if syncs didn't add them
+func Rollback(ctx context.Context, cfg Config, backup *Backup) (RollbackReport, error) {
+	var report RollbackReport
+
+	seerrBlocklistClient, err := cfg.blocklistService()
+	if err != nil {
+		return report, err
+	}
+	added, err := loadAdditions(cfg.StateDir)
+	if err != nil {
+		return report, err
+	}
+	defer func() {
+		if err := added.save(); err != nil {
+			log.Printf("Error saving added series: %v", err)
+		}
+	}()
+
+	current, partial, err := getBlocklist(ctx, seerrBlocklistClient)
+	if err != nil {
+		return report, err
+	}
+	if partial {
+		return report, errors.New("blocklist couldn't be fetched in full")
+	}
+	// So the rollback can be rolled back in turn
+	seerrBlocklistClient = &backupOnWrite{BlocklistService: seerrBlocklistClient, backup: func() error {
+		return saveBackup(cfg.StateDir, current, false)
+	}}
+
+	type key struct {
+		tmdbId    int
+		mediaType seerrApi.MediaType
+	}
+	inBackup := make(map[key]struct{}, len(backup.Entries))
+	for _, entry := range backup.Entries {
+		inBackup[key{entry.TmdbId, entry.MediaType}] = struct{}{}
+	}
+	inCurrent := make(map[key]struct{}, len(current))
+	for _, entry := range current {
+		inCurrent[key{entry.TmdbId, entry.MediaType}] = struct{}{}
+	}
+
+	// Removing first frees up the TMDB IDs that entries of the other media type may need
+	if !backup.Partial {
+		for _, entry := range current {
+			if ctx.Err() != nil {
+				return report, context.Cause(ctx)
+			}
+			if _, ok := inBackup[key{entry.TmdbId, entry.MediaType}]; ok {
+				continue
+			}
+			if !cfg.Force && (entry.MediaType != seerrApi.MediaTypeTv || !added.isAdded(entry.TmdbId)) {
+				report.Kept++
+				continue
+			}
+
+			if cfg.Verbose {
+				console.Removed("Removing %s (%v) from blocklist\n", entry.Title, entry.TmdbId)
+			}
+			if err := seerrBlocklistClient.Delete(ctx, fmt.Sprintf("/%d", entry.TmdbId), nil, nil); err != nil {
+				log.Printf("Error removing %s (%v) from blocklist: %v", entry.Title, entry.TmdbId, err)
+				report.Failed++
+				continue
+			}
+			report.Removed++
+			added.remove(entry.TmdbId)
+		}
+	}
+
+	for _, entry := range backup.Entries {
+		if ctx.Err() != nil {
+			return report, context.Cause(ctx)
+		}
+		if _, ok := inCurrent[key{entry.TmdbId, entry.MediaType}]; ok {
+			continue
+		}
+
+		body := seerrApi.PostBlocklistJSONRequestBody{TmdbId: entry.TmdbId, MediaType: entry.MediaType, Title: entry.Title}
+		if entry.User != nil {
+			body.User = entry.User.Id
+		} else if len(cfg.UserIds) > 0 {
+			body.User = cfg.UserIds[0]
+		}
+		if cfg.Verbose {
+			console.Added("Restoring %s (%v) to blocklist\n", entry.Title, entry.TmdbId)
+		}
+		if err := seerrBlocklistClient.Post(ctx, "", nil, &body, nil); err != nil {
+			log.Printf("Error restoring %s (%v) to blocklist: %v", entry.Title, entry.TmdbId, err)
+			report.Failed++
+			continue
+		}
+		report.Restored++
+	}
+
+	return report, nil
+}
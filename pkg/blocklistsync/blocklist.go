@@ -0,0 +1,324 @@
+package blocklistSync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"anime-to-seerr-blocklist/internal/console"
+	"anime-to-seerr-blocklist/internal/seerr"
+)
+
+// getBlocklistPage fetches a page of the blocklist, retrying transient failures with exponential backoff
+func getBlocklistPage(ctx context.Context, seerrBlocklistClient BlocklistService, values url.Values, resp *seerrApi.GetBlocklistResponse) error {
+	const attempts = 4
+	delay := 2 * time.Second
+
+	for attempt := 1; ; attempt++ {
+		err := seerrBlocklistClient.Get(ctx, "", values, resp)
+		if err == nil || attempt == attempts || !isTransient(err) || ctx.Err() != nil {
+			return err
+		}
+
+		log.Printf("Error fetching blocklist, retrying in %v: %v", delay, err)
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// getAlreadyBlocklisted returns the TMDB IDs of the blocklisted series
+func getAlreadyBlocklisted(ctx context.Context, seerrBlocklistClient BlocklistService) (map[int]struct{}, bool, error) {
+	entries, partial, err := getBlocklist(ctx, seerrBlocklistClient)
+	if err != nil {
+		return nil, false, err
+	}
+	return tvTmdbIds(entries), partial, nil
+}
+
+func tvTmdbIds(entries []seerrApi.BlocklistEntry) map[int]struct{} {
+	blocklisted := make(map[int]struct{}, len(entries))
+	for _, entry := range entries {
+		if entry.MediaType == seerrApi.MediaTypeTv {
+			blocklisted[entry.TmdbId] = struct{}{}
+		}
+	}
+	return blocklisted
+}
+
+// getBlocklist returns every entry of the blocklist. The pages after the first are fetched concurrently, at the page
+// size the server used for the first page, which may be smaller than asked for. If one of them can't be fetched in
+// full, it's skipped and partial is set, as the series on it may then be posted again
+func getBlocklist(ctx context.Context, seerrBlocklistClient BlocklistService) (entries []seerrApi.BlocklistEntry, partial bool, err error) {
+	const concurrency = 4
+
+	take := seerrApi.MaxTake
+	pageValues := func(skip int) url.Values {
+		return url.Values{
+			"take":   []string{strconv.Itoa(take)},
+			"skip":   []string{strconv.Itoa(skip)},
+			"filter": []string{seerrApi.GetBlocklistParamsFilterAll},
+			//"search": []string{""},
+		}
+	}
+
+	var mu sync.Mutex
+	addResults := func(resp *seerrApi.GetBlocklistResponse) {
+		mu.Lock()
+		defer mu.Unlock()
+		entries = append(entries, resp.Results...)
+	}
+
+	var first seerrApi.GetBlocklistResponse
+	if err = getBlocklistPage(ctx, seerrBlocklistClient, pageValues(0), &first); err != nil {
+		return
+	}
+	entries = make([]seerrApi.BlocklistEntry, 0, first.PageInfo.Results)
+	addResults(&first)
+	if len(first.Results) == 0 {
+		return
+	}
+
+	take = seerrApi.PageSize(take, first.PageInfo, len(first.Results))
+	total := first.PageInfo.Results
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for skip := take; skip < total; skip += take {
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			var resp seerrApi.GetBlocklistResponse
+			if err := getBlocklistPage(ctx, seerrBlocklistClient, pageValues(skip), &resp); err != nil {
+				if ctx.Err() == nil {
+					log.Printf("Error fetching blocklist from entry %d, continuing without it: %v", skip, err)
+				}
+				mu.Lock()
+				partial = true
+				mu.Unlock()
+				return
+			}
+			if want := min(take, total-skip); len(resp.Results) < want {
+				// Entries removed meanwhile shift the rest to earlier pages, so some may have been missed
+				log.Printf("Error fetching blocklist from entry %d: got %d entries instead of %d, continuing without the rest", skip, len(resp.Results), want)
+				mu.Lock()
+				partial = true
+				mu.Unlock()
+			}
+			addResults(&resp)
+		})
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		err = context.Cause(ctx)
+	}
+	return
+}
+
+// Entries returns every entry of the blocklist, and whether it could only be fetched in part
+func Entries(ctx context.Context, blocklist BlocklistService) ([]seerrApi.BlocklistEntry, bool, error) {
+	return getBlocklist(ctx, blocklist)
+}
+
+// Blocklisted returns the TMDB IDs of the series on the blocklist, and whether it could only be fetched in part
+func Blocklisted(ctx context.Context, blocklist BlocklistService) (map[int]struct{}, bool, error) {
+	return getAlreadyBlocklisted(ctx, blocklist)
+}
+
+// addToBlocklist blocklists every series not already blocklisted. Seerr keeps a single blocklist entry per title, so
+// with multiple users the new entries are attributed to each user in turn, and a series whose TMDB ID is taken is left
+// to the conflict resolver. It reports whether every series was processed, rather than stopping early because of ctx,
+// MaxAdditions or FailFast. With Config.Bulk, the series are first added in batches, and adding them one at a time is
+// the fallback for those that couldn't be
+func (s *syncer) addToBlocklist(ctx context.Context, series []Series) (finished bool) {
+	if s.cfg.Bulk {
+		s.addInBulk(ctx, series)
+	}
+
+	blocklistReqBody := &seerrApi.PostBlocklistJSONRequestBody{
+		MediaType: seerrApi.MediaTypeTv,
+	}
+
+	for i, p := range series {
+		if ctx.Err() != nil || s.report.Aborted || (s.cfg.MaxAdditions > 0 && s.report.Added >= s.cfg.MaxAdditions) {
+			return false
+		}
+		if s.cfg.Progress != nil {
+			s.cfg.Progress(i, len(series))
+		}
+
+		tmdbId := p.TmdbId
+		if tmdbId == 0 || (s.progress.isCompleted(tmdbId) && !s.retries.isQueued(tmdbId)) {
+			continue
+		}
+		if _, ok := s.posted[tmdbId]; ok {
+			// Added in bulk
+			continue
+		}
+
+		if _, ok := s.blocklisted[tmdbId]; !ok {
+			if s.cfg.Verbose {
+				console.Added("Adding %s (%v)\n", p.Title, tmdbId)
+			}
+			blocklistReqBody.TmdbId = tmdbId
+			blocklistReqBody.Title = p.Title
+			blocklistReqBody.User = s.cfg.UserIds[s.report.Added%len(s.cfg.UserIds)]
+		retry:
+			err := s.seerrBlocklistClient.Post(ctx, "", nil, blocklistReqBody, nil)
+			if err != nil {
+				_, ok = s.blocklisted[tmdbId]
+				if httpErr, ok2 := errors.AsType[*seerrApi.HTTPError](err); !ok && ok2 && httpErr.StatusCode == http.StatusPreconditionFailed {
+					// On TMDB, IDs can be shared between shows and movies; Seerr doesn't differentiate, so the existing
+					// entry may be a movie to replace with the anime series
+					s.blocklisted[tmdbId] = struct{}{}
+					s.report.Conflicts++
+					if s.conflicts.resolve(ctx, tmdbId, p.Title) {
+						goto retry
+					}
+					s.complete(tmdbId)
+				} else {
+					if s.cfg.Verbose {
+						log.Printf("Error adding %s (%v) to blocklist: %v", p.Title, tmdbId, err)
+					}
+					// Not on the blocklist after all if it failed again once the conflicting movie was removed
+					delete(s.blocklisted, tmdbId)
+					s.failed("adding to blocklist", p.Title, tmdbId, err)
+					s.retries.failed(tmdbId, p.Title, err)
+					s.report.Failed++
+				}
+			} else {
+				s.succeeded(p, blocklistReqBody.User)
+			}
+		} else {
+			s.report.AlreadyBlocklisted++
+			s.retries.succeeded(tmdbId)
+		}
+	}
+
+	if s.cfg.Progress != nil {
+		s.cfg.Progress(len(series), len(series))
+	}
+	return true
+}
+
+// Series per request to a bulk endpoint, cutting thousands of requests to dozens while keeping a batch that fails
+// cheap to add one at a time instead
+const bulkBatchSize = 100
+
+// addInBulk adds the series not already blocklisted in batches. A batch with a series conflicting with a movie is left
+// to be added one at a time, as are it and the rest once a batch fails otherwise
+func (s *syncer) addInBulk(ctx context.Context, series []Series) {
+	var pending []Series
+	for _, p := range series {
+		if p.TmdbId == 0 || (s.progress.isCompleted(p.TmdbId) && !s.retries.isQueued(p.TmdbId)) {
+			continue
+		}
+		if _, ok := s.blocklisted[p.TmdbId]; !ok {
+			pending = append(pending, p)
+		}
+	}
+
+	for len(pending) > 0 {
+		n := min(len(pending), bulkBatchSize)
+		if s.cfg.MaxAdditions > 0 {
+			n = min(n, s.cfg.MaxAdditions-s.report.Added)
+		}
+		if ctx.Err() != nil || s.report.Aborted || n <= 0 {
+			return
+		}
+
+		batch := pending[:n]
+		body := &seerrApi.PostBlocklistBulkJSONRequestBody{Items: make([]seerrApi.PostBlocklistJSONRequestBody, 0, n)}
+		for i, p := range batch {
+			body.Items = append(body.Items, seerrApi.PostBlocklistJSONRequestBody{
+				TmdbId:    p.TmdbId,
+				MediaType: seerrApi.MediaTypeTv,
+				Title:     p.Title,
+				User:      s.cfg.UserIds[(s.report.Added+i)%len(s.cfg.UserIds)],
+			})
+		}
+		if err := s.seerrBlocklistClient.Post(ctx, "/bulk", nil, body, nil); err != nil {
+			if s.cfg.Verbose {
+				log.Printf("Error adding %d series to blocklist at once, adding them one at a time instead: %v", n, err)
+			}
+			// A conflict only spoils its own batch
+			if httpErr, ok := errors.AsType[*seerrApi.HTTPError](err); ok && httpErr.StatusCode == http.StatusPreconditionFailed {
+				pending = pending[n:]
+				continue
+			}
+			return
+		}
+
+		for i, p := range batch {
+			if s.cfg.Verbose {
+				console.Added("Added %s (%v)\n", p.Title, p.TmdbId)
+			}
+			s.succeeded(p, body.Items[i].User)
+		}
+		pending = pending[n:]
+	}
+}
+
+// succeeded records p as added to the blocklist by this run, attributed to user
+func (s *syncer) succeeded(p Series, user int) {
+	s.blocklisted[p.TmdbId] = struct{}{}
+	s.posted[p.TmdbId] = struct{}{}
+	s.report.Added++
+	s.consecutiveFailures = 0
+	s.added.add(p.TmdbId, p.Title, user)
+	s.retries.succeeded(p.TmdbId)
+	s.complete(p.TmdbId)
+}
+
+// complete checkpoints a series as done with, having landed on the blocklist or had its conflict recorded. Series that
+// failed aren't, so that they're tried again on resume
+func (s *syncer) complete(tmdbId int) {
+	if err := s.progress.complete(tmdbId); err != nil {
+		log.Printf("Error saving progress: %v", err)
+	}
+}
+
+// unblockAllowlisted removes allowlisted series that syncs blocklisted before they were allowlisted
+func (s *syncer) unblockAllowlisted(ctx context.Context) {
+	for tmdbId := range s.cfg.Allowlist {
+		if ctx.Err() != nil || s.report.Aborted {
+			return
+		}
+		if _, ok := s.blocklisted[tmdbId]; !ok {
+			continue
+		}
+		if !s.cfg.Force && !s.added.isAdded(tmdbId) {
+			if s.cfg.Verbose {
+				console.Skipped("Keeping %v on blocklist as it wasn't added by a sync\n", tmdbId)
+			}
+			s.report.Protected++
+			continue
+		}
+
+		if s.cfg.Verbose {
+			console.Removed("Removing %v from blocklist\n", tmdbId)
+		}
+		if err := s.seerrBlocklistClient.Delete(ctx, fmt.Sprintf("/%d", tmdbId), nil, nil); err != nil {
+			if s.cfg.Verbose {
+				log.Printf("Error removing %v from blocklist: %v", tmdbId, err)
+			}
+			s.failed("removing from blocklist", "", tmdbId, err)
+			continue
+		}
+		s.consecutiveFailures = 0
+		delete(s.blocklisted, tmdbId)
+		s.added.remove(tmdbId)
+		s.report.Unblocked++
+	}
+}
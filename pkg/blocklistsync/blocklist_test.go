@@ -0,0 +1,114 @@
+package blocklistSync
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+
+	"anime-to-seerr-blocklist/internal/rest"
+	"anime-to-seerr-blocklist/internal/seerr"
+)
+
+// pagedBlocklist serves a blocklist of total series, with TMDB IDs from 1, a page at a time like Seerr
+type pagedBlocklist struct {
+	BlocklistService
+	total int
+	// limit caps take if set, saying so in pageInfo if reportLimit
+	limit       int
+	reportLimit bool
+	// fail fails the pages starting at these entries, and short drops the last entry of theirs, as if removed meanwhile
+	fail, short map[int]bool
+
+	mu    sync.Mutex
+	takes []int
+}
+
+func (f *pagedBlocklist) Get(_ context.Context, _ string, values url.Values, respBody any) error {
+	take, _ := strconv.Atoi(values.Get("take"))
+	skip, _ := strconv.Atoi(values.Get("skip"))
+	f.mu.Lock()
+	f.takes = append(f.takes, take)
+	f.mu.Unlock()
+
+	if f.fail[skip] {
+		return &restApi.HTTPError{StatusCode: http.StatusNotFound}
+	}
+	if f.limit > 0 {
+		take = min(take, f.limit)
+	}
+	resp := respBody.(*seerrApi.GetBlocklistResponse)
+	resp.PageInfo.Results = f.total
+	if f.reportLimit {
+		resp.PageInfo.PageSize = take
+	}
+	end := min(skip+take, f.total)
+	if f.short[skip] {
+		end--
+	}
+	for tmdbId := skip + 1; tmdbId <= end; tmdbId++ {
+		resp.Results = append(resp.Results, seerrApi.BlocklistEntry{TmdbId: tmdbId, MediaType: seerrApi.MediaTypeTv})
+	}
+	return nil
+}
+
+func TestGetBlocklist(t *testing.T) {
+	tests := []struct {
+		name      string
+		blocklist *pagedBlocklist
+		// missing are the TMDB IDs left out
+		missing     []int
+		wantPartial bool
+		// wantTake is what the pages after the first should ask for
+		wantTake int
+	}{
+		{name: "empty", blocklist: &pagedBlocklist{}},
+		{name: "one page", blocklist: &pagedBlocklist{total: 10}},
+		{name: "capped take reported", blocklist: &pagedBlocklist{total: 10, limit: 3, reportLimit: true}, wantTake: 3},
+		{name: "capped take unreported", blocklist: &pagedBlocklist{total: 10, limit: 3}, wantTake: 3},
+		{name: "capped take with exact pages", blocklist: &pagedBlocklist{total: 9, limit: 3}, wantTake: 3},
+		{name: "page failing", blocklist: &pagedBlocklist{total: 10, limit: 3, fail: map[int]bool{3: true}}, missing: []int{4, 5, 6}, wantPartial: true, wantTake: 3},
+		{name: "page short", blocklist: &pagedBlocklist{total: 10, limit: 3, short: map[int]bool{6: true}}, missing: []int{9}, wantPartial: true, wantTake: 3},
+		{name: "last page short as expected", blocklist: &pagedBlocklist{total: 10, limit: 4}, wantTake: 4},
+		{name: "last page shorter than expected", blocklist: &pagedBlocklist{total: 10, limit: 4, short: map[int]bool{8: true}}, missing: []int{10}, wantPartial: true, wantTake: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, partial, err := getBlocklist(t.Context(), tt.blocklist)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if partial != tt.wantPartial {
+				t.Errorf("partial = %t, want %t", partial, tt.wantPartial)
+			}
+
+			var got, want []int
+			for _, entry := range entries {
+				got = append(got, entry.TmdbId)
+			}
+			slices.Sort(got)
+			for tmdbId := 1; tmdbId <= tt.blocklist.total; tmdbId++ {
+				if !slices.Contains(tt.missing, tmdbId) {
+					want = append(want, tmdbId)
+				}
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("entries = %v, want %v", got, want)
+			}
+
+			if takes := tt.blocklist.takes; takes[0] != seerrApi.MaxTake {
+				t.Errorf("first page took %d, want %d", takes[0], seerrApi.MaxTake)
+			}
+			for _, take := range tt.blocklist.takes[1:] {
+				if take != tt.wantTake {
+					t.Errorf("later pages took %v, want %d", tt.blocklist.takes[1:], tt.wantTake)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package blocklistSync
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"anime-to-seerr-blocklist/internal/atomicfile"
+)
+
+const checkpointFile = "progress.json"
+
+// Saving after every addition would double the disk writes of a sync for little benefit
+const checkpointInterval = 50
+
+// checkpoint records the series processed by an unfinished sync, so that an interrupted sync can resume instead of
+// starting over
+type checkpoint struct {
+	filename  string
+	completed map[int]struct{}
+	unsaved   int
+}
+
+func loadCheckpoint(stateDir string) (*checkpoint, error) {
+	c := &checkpoint{
+		filename:  filepath.Join(stateDir, checkpointFile),
+		completed: make(map[int]struct{}),
+	}
+
+	b, err := os.ReadFile(c.filename)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return c, nil
+		}
+		return nil, err
+	}
+
+	var completed []int
+	if err = json.Unmarshal(b, &completed); err != nil {
+		return nil, err
+	}
+	for _, tmdbId := range completed {
+		c.completed[tmdbId] = struct{}{}
+	}
+
+	return c, nil
+}
+
+func (c *checkpoint) isCompleted(tmdbId int) bool {
+	_, ok := c.completed[tmdbId]
+	return ok
+}
+
+func (c *checkpoint) complete(tmdbId int) error {
+	c.completed[tmdbId] = struct{}{}
+	c.unsaved++
+	if c.unsaved < checkpointInterval {
+		return nil
+	}
+	return c.save()
+}
+
+func (c *checkpoint) save() error {
+	completed := make([]int, 0, len(c.completed))
+	for tmdbId := range c.completed {
+		completed = append(completed, tmdbId)
+	}
+
+	b, err := json.Marshal(completed)
+	if err != nil {
+		return err
+	}
+	if err = atomicFile.WriteFile(c.filename, b); err != nil {
+		return err
+	}
+
+	c.unsaved = 0
+	return nil
+}
+
+// finish discards the checkpoint once a sync has run to completion
+func (c *checkpoint) finish() error {
+	if err := os.Remove(c.filename); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
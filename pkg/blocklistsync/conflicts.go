@@ -0,0 +1,181 @@
+package blocklistSync
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"anime-to-seerr-blocklist/internal/rest"
+	"anime-to-seerr-blocklist/internal/seerr"
+	"anime-to-seerr-blocklist/internal/tmdb"
+)
+
+const conflictsFile = "conflicts.jsonl"
+
+// What was done about a conflict
+const (
+	conflictReplaced = "replaced"
+	conflictKept     = "kept"
+	conflictFailed   = "failed"
+)
+
+// Conflict is logged for every series that Seerr refused to blocklist because its TMDB ID already is
+type Conflict struct {
+	Time              time.Time          `json:"time"`
+	TmdbId            int                `json:"tmdbId"`
+	Title             string             `json:"title"`
+	ExistingMediaType seerrApi.MediaType `json:"existingMediaType,omitzero"`
+	ExistingTitle     string             `json:"existingTitle,omitzero"`
+	Action            string             `json:"action"`
+	Reason            string             `json:"reason"`
+}
+
+// conflictResolver decides what to do when Seerr refuses to blocklist a series because its TMDB ID is already
+// blocklisted. As Seerr doesn't tell series and movies apart, that's usually a movie sharing the series' ID, which is
+// replaced with the series, but it's first checked that the existing entry isn't the series itself, that a sync added
+// it unless forced and, given a TMDB API key, that the ID really is a series'
+type conflictResolver struct {
+	seerrBlocklistClient BlocklistService
+	tmdbTvClient         *tmdbApi.Client
+	added                *additions
+	filename             string
+	// partial is whether the blocklist was only fetched in part, in which case a conflict is likely the series itself
+	partial bool
+	// force allows replacing entries syncs didn't add
+	force   bool
+	verbose bool
+}
+
+func newConflictResolver(stateDir string, seerrBlocklistClient BlocklistService, added *additions, tmdbApiKey string, partial, force, verbose bool) *conflictResolver {
+	r := &conflictResolver{
+		seerrBlocklistClient: seerrBlocklistClient,
+		added:                added,
+		filename:             filepath.Join(stateDir, conflictsFile),
+		partial:              partial,
+		force:                force,
+		verbose:              verbose,
+	}
+	if tmdbApiKey != "" {
+		if tmdbTvClient, err := tmdbApi.NewClient(tmdbApiKey, "tv"); err == nil {
+			r.tmdbTvClient = tmdbTvClient
+		}
+	}
+	return r
+}
+
+// resolve handles the conflict for the series tmdbId, reporting whether blocklisting it should be retried
+func (r *conflictResolver) resolve(ctx context.Context, tmdbId int, title string) (retry bool) {
+	record := Conflict{
+		Time:   time.Now(),
+		TmdbId: tmdbId,
+		Title:  title,
+	}
+	defer func() {
+		r.record(&record)
+	}()
+
+	var existing seerrApi.GetBlocklistTmdbIdResponse
+	err := r.seerrBlocklistClient.Get(ctx, fmt.Sprintf("/%d", tmdbId), nil, &existing)
+	if err != nil {
+		// Older versions of Seerr can't look up an entry
+		if r.partial {
+			record.Action, record.Reason = conflictKept, "blocklist fetched in part, assuming the series is on it"
+			return false
+		}
+	} else {
+		record.ExistingMediaType, record.ExistingTitle = existing.MediaType, existing.Title
+		if existing.MediaType == seerrApi.MediaTypeTv {
+			record.Action, record.Reason = conflictKept, "series already blocklisted"
+			return false
+		}
+	}
+
+	if !r.force && !r.added.isAdded(tmdbId) {
+		record.Action, record.Reason = conflictKept, "existing entry wasn't added by a sync"
+		return false
+	}
+
+	if r.tmdbTvClient != nil {
+		var details tmdbApi.TvDetails
+		err = r.tmdbTvClient.Get(ctx, fmt.Sprintf("/%d", tmdbId), nil, &details)
+		if httpErr, ok := errors.AsType[*restApi.HTTPError](err); ok && httpErr.StatusCode == http.StatusNotFound {
+			record.Action, record.Reason = conflictKept, "not a series on TMDB"
+			return false
+		} else if err != nil {
+			record.Action, record.Reason = conflictFailed, fmt.Sprintf("looking up series on TMDB: %v", err)
+			return false
+		}
+	}
+
+	if err = r.seerrBlocklistClient.Delete(ctx, fmt.Sprintf("/%d", tmdbId), nil, nil); err != nil {
+		record.Action, record.Reason = conflictFailed, fmt.Sprintf("removing existing entry: %v", err)
+		return false
+	}
+	record.Action, record.Reason = conflictReplaced, "existing entry isn't the series"
+	return true
+}
+
+// record appends record to the conflicts log
+func (r *conflictResolver) record(record *Conflict) {
+	if r.verbose || record.Action == conflictFailed {
+		log.Printf("Conflict for %s (%v): %s, %s", record.Title, record.TmdbId, record.Action, record.Reason)
+	}
+
+	b, err := json.Marshal(record)
+	if err != nil {
+		log.Printf("Error recording conflict: %v", err)
+		return
+	}
+
+	f, err := os.OpenFile(r.filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		log.Printf("Error recording conflict: %v", err)
+		return
+	}
+	defer f.Close()
+
+	if _, err = f.Write(append(b, '\n')); err != nil {
+		log.Printf("Error recording conflict: %v", err)
+	}
+}
+
+// RecentConflicts returns up to the last n conflicts logged in stateDir, newest first
+func RecentConflicts(stateDir string, n int) ([]Conflict, error) {
+	f, err := os.Open(filepath.Join(stateDir, conflictsFile))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var conflicts []Conflict
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var conflict Conflict
+		if err = json.Unmarshal(scanner.Bytes(), &conflict); err != nil {
+			// Torn by a crash mid-write
+			continue
+		}
+		conflicts = append(conflicts, conflict)
+		if len(conflicts) > n {
+			conflicts = conflicts[1:]
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.Reverse(conflicts)
+	return conflicts, nil
+}
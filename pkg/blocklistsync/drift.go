@@ -0,0 +1,88 @@
+package blocklistSync
+
+import (
+	"context"
+	"slices"
+
+	"anime-to-seerr-blocklist/internal/seerr"
+)
+
+// Drift is how the blocklist differs from what the series and the local state say it should hold
+type Drift struct {
+	// Removed were added by a sync but are no longer blocklisted, such as when they've been removed in Seerr's UI
+	Removed []Series
+	// Missing were blocklisted when the last sync finished, according to its record, but no longer are
+	Missing []Series
+	// Conflicts aren't blocklisted as series, but are kept out by a blocklisted movie with the same TMDB ID
+	Conflicts []Series
+	// Foreign are blocklisted but neither in the series nor added by a sync, so were blocklisted by other means
+	Foreign []int
+	// Partial is whether the blocklist could only be fetched in part, so Removed and Missing may be overstated
+	Partial bool
+}
+
+// CheckDrift compares the blocklist on the Seerr instance with cfg's series and the state of previous syncs in
+// cfg.StateDir, without changing either
+func CheckDrift(ctx context.Context, cfg Config) (Drift, error) {
+	var drift Drift
+
+	seerrBlocklistClient, err := cfg.blocklistService()
+	if err != nil {
+		return drift, err
+	}
+	if cfg.Series == nil && cfg.Source != nil {
+		if cfg.Series, err = cfg.Source.Series(ctx); err != nil {
+			return drift, err
+		}
+	}
+
+	added, err := loadAdditions(cfg.StateDir)
+	if err != nil {
+		return drift, err
+	}
+	synced, err := loadSnapshot(cfg.StateDir)
+	if err != nil {
+		return drift, err
+	}
+
+	var entries []seerrApi.BlocklistEntry
+	entries, drift.Partial, err = getBlocklist(ctx, seerrBlocklistClient)
+	if err != nil {
+		return drift, err
+	}
+	blocklisted := tvTmdbIds(entries)
+	movies := make(map[int]struct{})
+	for _, entry := range entries {
+		if entry.MediaType == seerrApi.MediaTypeMovie {
+			movies[entry.TmdbId] = struct{}{}
+		}
+	}
+
+	inSeries := make(map[int]struct{}, len(cfg.Series))
+	for _, p := range cfg.Series {
+		if p.TmdbId == 0 {
+			continue
+		}
+		inSeries[p.TmdbId] = struct{}{}
+		if _, ok := blocklisted[p.TmdbId]; ok {
+			continue
+		}
+
+		if _, ok := movies[p.TmdbId]; ok {
+			drift.Conflicts = append(drift.Conflicts, p)
+		} else if added.isAdded(p.TmdbId) {
+			drift.Removed = append(drift.Removed, p)
+		} else if _, ok := synced.tmdbIds[p.TmdbId]; ok {
+			drift.Missing = append(drift.Missing, p)
+		}
+	}
+
+	for tmdbId := range blocklisted {
+		if _, ok := inSeries[tmdbId]; !ok && !added.isAdded(tmdbId) {
+			drift.Foreign = append(drift.Foreign, tmdbId)
+		}
+	}
+	slices.Sort(drift.Foreign)
+
+	return drift, nil
+}
@@ -0,0 +1,95 @@
+package blocklistSync
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"anime-to-seerr-blocklist/internal/rest"
+)
+
+// How many titles each group of failures lists as examples
+const failureExamples = 3
+
+// FailureGroup counts the changes to the blocklist that failed for the same cause
+type FailureGroup struct {
+	Action   string   `json:"action"`
+	Cause    string   `json:"cause"`
+	Count    int      `json:"count"`
+	Examples []string `json:"examples"`
+}
+
+// failures groups the errors of a sync by cause, so that an outage makes for a summary of a few lines instead of a
+// line per series
+type failures struct {
+	groups map[[2]string]*FailureGroup
+}
+
+func newFailures() *failures {
+	return &failures{groups: make(map[[2]string]*FailureGroup)}
+}
+
+// add records that action, such as adding to the blocklist, failed for the series with err
+func (f *failures) add(action, title string, tmdbId int, err error) {
+	cause := failureCause(err)
+	group := f.groups[[2]string{action, cause}]
+	if group == nil {
+		group = &FailureGroup{Action: action, Cause: cause}
+		f.groups[[2]string{action, cause}] = group
+	}
+	group.Count++
+	if len(group.Examples) < failureExamples {
+		example := strconv.Itoa(tmdbId)
+		if title != "" {
+			example = fmt.Sprintf("%s (%v)", title, tmdbId)
+		}
+		group.Examples = append(group.Examples, example)
+	}
+}
+
+// failureCause describes the root cause of err, leaving out what differs between series such as the URL
+func failureCause(err error) string {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return "interrupted"
+	}
+	if httpErr, ok := errors.AsType[*restApi.HTTPError](err); ok {
+		return fmt.Sprintf("HTTP %d %s", httpErr.StatusCode, http.StatusText(httpErr.StatusCode))
+	}
+
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return err.Error()
+		}
+		err = next
+	}
+}
+
+// summary returns the groups, largest first
+func (f *failures) summary() []FailureGroup {
+	groups := make([]FailureGroup, 0, len(f.groups))
+	for _, group := range f.groups {
+		groups = append(groups, *group)
+	}
+	slices.SortFunc(groups, func(a, b FailureGroup) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), strings.Compare(a.Action, b.Action), strings.Compare(a.Cause, b.Cause))
+	})
+	return groups
+}
+
+// log writes the summary of the failures, if any
+func (f *failures) log() {
+	for _, group := range f.summary() {
+		examples := strings.Join(group.Examples, ", ")
+		if group.Count > len(group.Examples) {
+			examples += ", ..."
+		}
+		log.Printf("Error %s for %d series: %s, e.g. %s", group.Action, group.Count, group.Cause, examples)
+	}
+}
@@ -0,0 +1,37 @@
+package blocklistSync
+
+import (
+	"context"
+	"net/url"
+
+	"golang.org/x/time/rate"
+)
+
+// rateLimited spaces out the changes made through a BlocklistService, so a large sync doesn't slow Seerr down for
+// everyone else using it. Reads aren't limited
+type rateLimited struct {
+	BlocklistService
+
+	limiter *rate.Limiter
+}
+
+func newRateLimited(service BlocklistService, perSecond float64, burst int) *rateLimited {
+	return &rateLimited{
+		BlocklistService: service,
+		limiter:          rate.NewLimiter(rate.Limit(perSecond), max(burst, 1)),
+	}
+}
+
+func (r *rateLimited) Post(ctx context.Context, endpoint string, queryParams url.Values, reqBody any, respBody any) error {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	return r.BlocklistService.Post(ctx, endpoint, queryParams, reqBody, respBody)
+}
+
+func (r *rateLimited) Delete(ctx context.Context, endpoint string, queryParams url.Values, reqBody any) error {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	return r.BlocklistService.Delete(ctx, endpoint, queryParams, reqBody)
+}
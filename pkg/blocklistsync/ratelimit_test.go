@@ -0,0 +1,54 @@
+package blocklistSync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"anime-to-seerr-blocklist/internal/seerr"
+)
+
+func TestRateLimited(t *testing.T) {
+	tests := []struct {
+		name      string
+		perSecond float64
+		burst     int
+		posts     int
+		// minElapsed is the least the posts should take, and maxElapsed the most
+		minElapsed, maxElapsed time.Duration
+	}{
+		{"within burst", 10, 3, 3, 0, 50 * time.Millisecond},
+		{"beyond burst", 20, 1, 3, 90 * time.Millisecond, time.Second},
+		{"burst below 1", 20, 0, 2, 40 * time.Millisecond, time.Second},
+		// Too fast to space out by a whole nanosecond, which mustn't stop it from limiting at all
+		{"above a billion per second", 2e9, 1, 1000, 0, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limited := newRateLimited(&fakeBlocklist{entries: map[int]seerrApi.MediaType{}}, tt.perSecond, tt.burst)
+			start := time.Now()
+			for i := range tt.posts {
+				if err := limited.Post(t.Context(), "", nil, &seerrApi.PostBlocklistJSONRequestBody{TmdbId: i + 1}, nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if elapsed := time.Since(start); elapsed < tt.minElapsed || elapsed > tt.maxElapsed {
+				t.Errorf("%d posts took %v, want between %v and %v", tt.posts, elapsed, tt.minElapsed, tt.maxElapsed)
+			}
+		})
+	}
+}
+
+func TestRateLimitedCancelled(t *testing.T) {
+	limited := newRateLimited(&fakeBlocklist{entries: map[int]seerrApi.MediaType{}}, 0.01, 1)
+	if err := limited.Delete(t.Context(), "/1", nil, nil); err == nil {
+		t.Fatal("deleting a series not on the blocklist succeeded")
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if err := limited.Delete(ctx, "/1", nil, nil); err == nil {
+		t.Error("waiting for a token despite the cancelled context succeeded")
+	}
+}
@@ -0,0 +1,296 @@
+package blocklistSync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"anime-to-seerr-blocklist/internal/console"
+	"anime-to-seerr-blocklist/internal/rest"
+	"anime-to-seerr-blocklist/internal/seerr"
+	"anime-to-seerr-blocklist/internal/tmdb"
+)
+
+// ReplacedMovie is a movie's blocklist entry that a conflict replaced with a series sharing its TMDB ID
+type ReplacedMovie struct {
+	Time        time.Time
+	TmdbId      int
+	Title       string
+	SeriesTitle string
+	// User blocklisted the movie, if a backup shows who, otherwise 0
+	User int
+	// Logged is whether the conflicts log records the movie being replaced, rather than the movie only sharing the
+	// series' TMDB ID
+	Logged bool
+}
+
+// ReplacedMovies returns the movies that conflicts replaced with series according to stateDir's conflicts log, newest
+// first. Where the existing entry couldn't be looked up at the time, the backup taken before the conflict tells
+// whether it was a movie
+func ReplacedMovies(stateDir string) ([]ReplacedMovie, error) {
+	conflicts, err := RecentConflicts(stateDir, math.MaxInt)
+	if err != nil {
+		return nil, err
+	}
+
+	filenames, err := Backups(stateDir)
+	if err != nil {
+		return nil, err
+	}
+	backups := make([]*Backup, 0, len(filenames))
+	for _, filename := range filenames {
+		backup, err := LoadBackup(stateDir, filepath.Base(filename))
+		if err != nil {
+			log.Printf("Error reading backup %s: %v", filepath.Base(filename), err)
+			continue
+		}
+		backups = append(backups, backup)
+	}
+	// backupBefore returns the movie entry for tmdbId in the last backup taken before t, if any
+	backupBefore := func(t time.Time, tmdbId int) *seerrApi.BlocklistEntry {
+		for i := len(backups) - 1; i >= 0; i-- {
+			if backups[i].Time.After(t) {
+				continue
+			}
+			for j := range backups[i].Entries {
+				if entry := &backups[i].Entries[j]; entry.TmdbId == tmdbId && entry.MediaType == seerrApi.MediaTypeMovie {
+					return entry
+				}
+			}
+			return nil
+		}
+		return nil
+	}
+
+	var movies []ReplacedMovie
+	seen := make(map[int]struct{})
+	for _, conflict := range conflicts {
+		if conflict.Action != conflictReplaced {
+			continue
+		}
+		if _, ok := seen[conflict.TmdbId]; ok {
+			continue
+		}
+
+		entry := backupBefore(conflict.Time, conflict.TmdbId)
+		if conflict.ExistingMediaType != seerrApi.MediaTypeMovie && entry == nil {
+			continue
+		}
+		seen[conflict.TmdbId] = struct{}{}
+
+		movie := ReplacedMovie{
+			Time:        conflict.Time,
+			TmdbId:      conflict.TmdbId,
+			Title:       conflict.ExistingTitle,
+			SeriesTitle: conflict.Title,
+			Logged:      true,
+		}
+		if entry != nil {
+			if movie.Title == "" {
+				movie.Title = entry.Title
+			}
+			if entry.User != nil {
+				movie.User = entry.User.Id
+			}
+		}
+		movies = append(movies, movie)
+	}
+	return movies, nil
+}
+
+// DamagedEntries returns the series on cfg's blocklist that syncs added whose TMDB ID is also a movie's, as the
+// entry may have replaced the movie's. Being found from the blocklist itself, they include those that the conflicts
+// log in cfg.StateDir lost track of, with what it and the backups do know filled in. The movies are looked up with
+// cfg.TmdbApiKey, which is required. Those the log records come first, then the rest by TMDB ID
+func DamagedEntries(ctx context.Context, cfg Config) ([]ReplacedMovie, error) {
+	const concurrency = 8
+
+	if cfg.TmdbApiKey == "" {
+		return nil, errors.New("a TMDB API key is required to look up movies")
+	}
+	tmdbMovieClient, err := tmdbApi.NewClient(cfg.TmdbApiKey, "movie")
+	if err != nil {
+		return nil, err
+	}
+	seerrBlocklistClient, err := cfg.blocklistService()
+	if err != nil {
+		return nil, err
+	}
+	added, err := loadAdditions(cfg.StateDir)
+	if err != nil {
+		return nil, err
+	}
+	logged, err := ReplacedMovies(cfg.StateDir)
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[int]ReplacedMovie, len(logged))
+	for _, movie := range logged {
+		byId[movie.TmdbId] = movie
+	}
+
+	entries, _, err := getBlocklist(ctx, seerrBlocklistClient)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		damaged []ReplacedMovie
+		lookups = make(chan seerrApi.BlocklistEntry)
+	)
+	for range concurrency {
+		wg.Go(func() {
+			for entry := range lookups {
+				var details tmdbApi.MovieDetails
+				err := tmdbMovieClient.Get(ctx, fmt.Sprintf("/%d", entry.TmdbId), nil, &details)
+				if httpErr, ok := errors.AsType[*restApi.HTTPError](err); ok && httpErr.StatusCode == http.StatusNotFound {
+					continue
+				} else if err != nil {
+					log.Printf("Error looking up movie %v on TMDB: %v", entry.TmdbId, err)
+					continue
+				}
+
+				movie, ok := byId[entry.TmdbId]
+				if !ok {
+					addition := added.entries[entry.TmdbId]
+					movie = ReplacedMovie{Time: addition.Time, TmdbId: entry.TmdbId, SeriesTitle: entry.Title}
+				}
+				if movie.Title == "" {
+					movie.Title = details.Title
+				}
+				if movie.SeriesTitle == "" {
+					movie.SeriesTitle = added.entries[entry.TmdbId].Title
+				}
+				mu.Lock()
+				damaged = append(damaged, movie)
+				mu.Unlock()
+			}
+		})
+	}
+	for _, entry := range entries {
+		if entry.MediaType != seerrApi.MediaTypeTv || !added.isAdded(entry.TmdbId) {
+			continue
+		}
+		select {
+		case lookups <- entry:
+		case <-ctx.Done():
+		}
+	}
+	close(lookups)
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil, context.Cause(ctx)
+	}
+
+	slices.SortFunc(damaged, func(a, b ReplacedMovie) int {
+		if a.Logged != b.Logged {
+			if a.Logged {
+				return -1
+			}
+			return 1
+		}
+		if a.Logged {
+			return b.Time.Compare(a.Time)
+		}
+		return a.TmdbId - b.TmdbId
+	})
+	return damaged, nil
+}
+
+// RepairReport summarises a repair
+type RepairReport struct {
+	Restored int
+	// AlreadyRestored counts the movies found back on the blocklist
+	AlreadyRestored int
+	// Kept counts the movies not restored as the series in their place wasn't added by a sync
+	Kept   int
+	Failed int
+}
+
+// Repair puts movies back on the blocklist of cfg's Seerr instance. Seerr keeps a single entry per TMDB ID, so the
+// series blocklisted in a movie's place is removed, which the next sync will find in conflict and, unless forced, leave
+// be as no sync added the movie
+func Repair(ctx context.Context, cfg Config, movies []ReplacedMovie) (RepairReport, error) {
+	var report RepairReport
+
+	seerrBlocklistClient, err := cfg.blocklistService()
+	if err != nil {
+		return report, err
+	}
+	added, err := loadAdditions(cfg.StateDir)
+	if err != nil {
+		return report, err
+	}
+	defer func() {
+		if err := added.save(); err != nil {
+			log.Printf("Error saving added series: %v", err)
+		}
+	}()
+
+	current, partial, err := getBlocklist(ctx, seerrBlocklistClient)
+	if err != nil {
+		return report, err
+	}
+	if partial {
+		return report, errors.New("blocklist couldn't be fetched in full")
+	}
+	seerrBlocklistClient = &backupOnWrite{BlocklistService: seerrBlocklistClient, backup: func() error {
+		return saveBackup(cfg.StateDir, current, false)
+	}}
+
+	inCurrent := make(map[int]seerrApi.MediaType, len(current))
+	for _, entry := range current {
+		inCurrent[entry.TmdbId] = entry.MediaType
+	}
+
+	for _, movie := range movies {
+		if ctx.Err() != nil {
+			return report, context.Cause(ctx)
+		}
+
+		switch mediaType, ok := inCurrent[movie.TmdbId]; {
+		case mediaType == seerrApi.MediaTypeMovie:
+			report.AlreadyRestored++
+			continue
+		case ok && !cfg.Force && !added.isAdded(movie.TmdbId):
+			report.Kept++
+			continue
+		case ok:
+			if cfg.Verbose {
+				console.Removed("Removing %s (%v) from blocklist\n", movie.SeriesTitle, movie.TmdbId)
+			}
+			if err := seerrBlocklistClient.Delete(ctx, fmt.Sprintf("/%d", movie.TmdbId), nil, nil); err != nil {
+				log.Printf("Error removing %s (%v) from blocklist: %v", movie.SeriesTitle, movie.TmdbId, err)
+				report.Failed++
+				continue
+			}
+			added.remove(movie.TmdbId)
+		}
+
+		body := seerrApi.PostBlocklistJSONRequestBody{TmdbId: movie.TmdbId, MediaType: seerrApi.MediaTypeMovie, Title: movie.Title, User: movie.User}
+		if body.User == 0 && len(cfg.UserIds) > 0 {
+			body.User = cfg.UserIds[0]
+		}
+		if cfg.Verbose {
+			console.Added("Restoring %s (%v) to blocklist\n", movie.Title, movie.TmdbId)
+		}
+		if err := seerrBlocklistClient.Post(ctx, "", nil, &body, nil); err != nil {
+			log.Printf("Error restoring %s (%v) to blocklist: %v", movie.Title, movie.TmdbId, err)
+			report.Failed++
+			continue
+		}
+		inCurrent[movie.TmdbId] = seerrApi.MediaTypeMovie
+		report.Restored++
+	}
+
+	return report, nil
+}
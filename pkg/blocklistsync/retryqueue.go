@@ -0,0 +1,163 @@
+package blocklistSync
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"anime-to-seerr-blocklist/internal/atomicfile"
+	"anime-to-seerr-blocklist/internal/rest"
+)
+
+const retryQueueFile = "retry-queue.json"
+
+type retryItem struct {
+	Title     string `json:"title"`
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"lastError"`
+}
+
+// Retry is a series queued to be retried on the next sync
+type Retry struct {
+	TmdbId    int    `json:"tmdbId"`
+	Title     string `json:"title"`
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"lastError"`
+}
+
+// retryQueue keeps the series that couldn't be blocklisted because of transient errors, so they're retried first on
+// the next run instead of waiting for the next full pass
+type retryQueue struct {
+	filename    string
+	items       map[int]*retryItem
+	maxAttempts int
+}
+
+func loadRetryQueue(stateDir string, maxAttempts int) (*retryQueue, error) {
+	q := &retryQueue{
+		filename:    filepath.Join(stateDir, retryQueueFile),
+		items:       make(map[int]*retryItem),
+		maxAttempts: maxAttempts,
+	}
+
+	b, err := os.ReadFile(q.filename)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return q, nil
+		}
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &q.items); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// PendingRetries returns the series queued in stateDir to be retried on the next sync, by TMDB ID
+func PendingRetries(stateDir string) ([]Retry, error) {
+	q, err := loadRetryQueue(stateDir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	retries := make([]Retry, 0, len(q.items))
+	for tmdbId, item := range q.items {
+		retries = append(retries, Retry{TmdbId: tmdbId, Title: item.Title, Attempts: item.Attempts, LastError: item.LastError})
+	}
+	slices.SortFunc(retries, func(a, b Retry) int {
+		return cmp.Compare(a.TmdbId, b.TmdbId)
+	})
+	return retries, nil
+}
+
+func (q *retryQueue) isQueued(tmdbId int) bool {
+	_, ok := q.items[tmdbId]
+	return ok
+}
+
+// prepend returns fdp reordered with the queued series first. Queued series that are no longer in fdp, such as those
+// allowlisted since, are forgotten
+func (q *retryQueue) prepend(fdp []Series) []Series {
+	if len(q.items) == 0 {
+		return fdp
+	}
+
+	queued := make([]Series, 0, len(fdp))
+	rest := make([]Series, 0, len(fdp))
+	inFdp := make(map[int]struct{}, len(q.items))
+	for _, p := range fdp {
+		if q.isQueued(p.TmdbId) {
+			queued = append(queued, p)
+			inFdp[p.TmdbId] = struct{}{}
+		} else {
+			rest = append(rest, p)
+		}
+	}
+
+	for tmdbId := range q.items {
+		if _, ok := inFdp[tmdbId]; !ok {
+			delete(q.items, tmdbId)
+		}
+	}
+
+	return append(queued, rest...)
+}
+
+func (q *retryQueue) succeeded(tmdbId int) {
+	delete(q.items, tmdbId)
+}
+
+// failed queues the series if err is transient, giving up on it after maxAttempts
+func (q *retryQueue) failed(tmdbId int, title string, err error) {
+	if !isTransient(err) {
+		delete(q.items, tmdbId)
+		return
+	}
+
+	item := q.items[tmdbId]
+	if item == nil {
+		item = &retryItem{Title: title}
+		q.items[tmdbId] = item
+	}
+	item.Attempts++
+	item.LastError = err.Error()
+
+	if q.maxAttempts > 0 && item.Attempts >= q.maxAttempts {
+		log.Printf("Giving up on %s (%v) after %d attempts", title, tmdbId, item.Attempts)
+		delete(q.items, tmdbId)
+	}
+}
+
+func (q *retryQueue) save() error {
+	if len(q.items) == 0 {
+		if err := os.Remove(q.filename); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	b, err := json.Marshal(q.items)
+	if err != nil {
+		return err
+	}
+	return atomicFile.WriteFile(q.filename, b)
+}
+
+// isTransient reports whether a request that failed with err might succeed if retried later
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return true
+	}
+	if err, ok := errors.AsType[*restApi.HTTPError](err); ok {
+		return err.StatusCode >= http.StatusInternalServerError || err.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
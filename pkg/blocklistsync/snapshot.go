@@ -0,0 +1,84 @@
+package blocklistSync
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"anime-to-seerr-blocklist/internal/atomicfile"
+)
+
+const snapshotFile = "synced.json"
+
+// snapshot records the series on the blocklist after the last finished sync, so that -incremental runs only consider the series added
+// to the mapping since and can skip fetching the blocklist when nothing changed, and so that CheckDrift can tell which
+// series should be blocklisted
+type snapshot struct {
+	filename string
+	tmdbIds  map[int]struct{}
+}
+
+func loadSnapshot(stateDir string) (*snapshot, error) {
+	s := &snapshot{
+		filename: filepath.Join(stateDir, snapshotFile),
+		tmdbIds:  make(map[int]struct{}),
+	}
+
+	b, err := os.ReadFile(s.filename)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return s, nil
+		}
+		return nil, err
+	}
+
+	var tmdbIds []int
+	if err = json.Unmarshal(b, &tmdbIds); err != nil {
+		return nil, err
+	}
+	for _, tmdbId := range tmdbIds {
+		s.tmdbIds[tmdbId] = struct{}{}
+	}
+
+	return s, nil
+}
+
+// changed returns the series that didn't land on the blocklist last time or are queued for retrying
+func (s *snapshot) changed(series []Series, retries *retryQueue) []Series {
+	return slices.DeleteFunc(slices.Clone(series), func(p Series) bool {
+		_, ok := s.tmdbIds[p.TmdbId]
+		return ok && !retries.isQueued(p.TmdbId)
+	})
+}
+
+// upToDate reports whether there's nothing to sync: no series to add and none synced last time that are now
+// allowlisted, and so to be unblocked
+func (s *snapshot) upToDate(series []Series, retries *retryQueue, allowlist map[int]struct{}) bool {
+	if len(s.changed(series, retries)) > 0 {
+		return false
+	}
+	for tmdbId := range allowlist {
+		if _, ok := s.tmdbIds[tmdbId]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+// save records series as synced
+func (s *snapshot) save(series []Series) error {
+	tmdbIds := make([]int, 0, len(series))
+	for _, p := range series {
+		tmdbIds = append(tmdbIds, p.TmdbId)
+	}
+	slices.Sort(tmdbIds)
+
+	b, err := json.Marshal(tmdbIds)
+	if err != nil {
+		return err
+	}
+	return atomicFile.WriteFile(s.filename, b)
+}
@@ -0,0 +1,309 @@
+// Package blocklistSync keeps a Seerr instance's blocklist in sync with a set of series, as the
+// anime-to-seerr-blocklist command does with anime, for embedding in other programs
+package blocklistSync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"slices"
+
+	"anime-to-seerr-blocklist/internal/seerr"
+)
+
+// ErrNoBlocklist is returned by Sync for servers without a blocklist, such as Overseerr
+var ErrNoBlocklist = errors.New("server has no blocklist")
+
+// BlocklistService is the part of Seerr's blocklist API that Sync uses, which *seerrApi.Client implements when bound
+// to the blocklist endpoint. Endpoints are relative to /api/v1/blocklist
+type BlocklistService interface {
+	Get(ctx context.Context, endpoint string, queryParams url.Values, respBody any) error
+	Post(ctx context.Context, endpoint string, queryParams url.Values, reqBody any, respBody any) error
+	Delete(ctx context.Context, endpoint string, queryParams url.Values, reqBody any) error
+}
+
+// MappingSource provides the series to blocklist
+type MappingSource interface {
+	Series(ctx context.Context) ([]Series, error)
+}
+
+// Series is a TV series to blocklist
+type Series struct {
+	TmdbId int
+	Title  string
+}
+
+type Config struct {
+	// SeerrHost is the URL of the Seerr instance and SeerrApiKey its API key
+	SeerrHost   string
+	SeerrApiKey string
+	// UserIds are the users new blocklist entries are attributed to in turn
+	UserIds []int
+	// Series are the series to blocklist, once each. If nil, they're fetched from Source
+	Series []Series
+	Source MappingSource
+	// Allowlist holds the TMDB IDs of series to remove from the blocklist
+	Allowlist map[int]struct{}
+	// StateDir keeps the progress of interrupted syncs and the series to retry across runs
+	StateDir string
+	// TmdbApiKey, an API read access token, is optional and used to check blocklist conflicts
+	TmdbApiKey string
+	// WriteRateLimit caps the changes made to the blocklist per second, allowing bursts of up to WriteBurst, 0 for no
+	// limit
+	WriteRateLimit float64
+	WriteBurst     int
+	// MaxAdditions stops the sync after adding this many series, 0 for no limit
+	MaxAdditions int
+	// Bulk adds series in batches through the blocklist's bulk endpoint, for servers with one. Adding them one at a
+	// time is the fallback, for servers without and for batches that fail
+	Bulk bool
+	// FailFast stops the sync after this many changes in a row fail, such as when the API key is revoked mid-sync, 0
+	// to carry on regardless
+	FailFast int
+	// RetryMaxAttempts gives up on a series that keeps failing after this many runs, 0 to never give up
+	RetryMaxAttempts int
+	// Incremental only considers the series that weren't on the blocklist after the last finished sync, such as those
+	// added to the mapping since or that failed or weren't approved
+	Incremental bool
+	// Verify fetches the blocklist again after syncing to check that the series added are on it
+	Verify bool
+	// Force allows removing blocklist entries that syncs didn't add. Otherwise they're taken to be the admin's and left
+	// alone, even when allowlisted or in the way of a series
+	Force bool
+	// Verbose prints every change made
+	Verbose bool
+	// Approve, if set, is passed the series about to be added to the blocklist and returns those to add. It returns
+	// stop to end the sync after adding them, leaving the rest for the next run
+	Approve func(ctx context.Context, pending []Series) (approved []Series, stop bool)
+	// Progress, if set, is called as the series are processed with how many of the total have been
+	Progress func(done, total int)
+
+	// HTTPClient replaces the client used to connect to Seerr
+	HTTPClient *http.Client
+	// Blocklist replaces the connection to Seerr's blocklist altogether, e.g. with a fake for testing
+	Blocklist BlocklistService
+}
+
+// Report summarises a sync
+type Report struct {
+	Added              int `json:"added"`
+	AlreadyBlocklisted int `json:"alreadyBlocklisted"`
+	Failed             int `json:"failed"`
+	Conflicts          int `json:"conflicts"`
+	Unblocked          int `json:"unblocked"`
+	// Protected counts the allowlisted series left on the blocklist as syncs didn't add them
+	Protected int `json:"protected"`
+	// Blocklisted is the size of the blocklist after the sync, or 0 if it wasn't fetched
+	Blocklisted int `json:"blocklisted"`
+	// Failures groups the changes that failed by cause
+	Failures []FailureGroup `json:"failures,omitempty"`
+	// Missing counts the series found missing from the blocklist by Config.Verify
+	Missing int `json:"missing"`
+	// Aborted is whether the sync stopped because Config.FailFast changes failed in a row
+	Aborted bool `json:"aborted,omitzero"`
+	// Finished is whether every series was processed, rather than the sync stopping early because of its context or
+	// Config.MaxAdditions
+	Finished bool `json:"finished"`
+	// UpToDate is whether an incremental sync found nothing to do
+	UpToDate bool `json:"upToDate"`
+	// Partial is whether the blocklist could only be fetched in part
+	Partial bool `json:"partial"`
+}
+
+type syncer struct {
+	cfg                  *Config
+	seerrBlocklistClient BlocklistService
+	blocklisted          map[int]struct{}
+	// Series added to the blocklist by this run, for Config.Verify to check
+	posted    map[int]struct{}
+	progress  *checkpoint
+	retries   *retryQueue
+	added     *additions
+	conflicts *conflictResolver
+	failures  *failures
+	// Changes that failed since the last to succeed
+	consecutiveFailures int
+	report              Report
+}
+
+// blocklistService returns cfg.Blocklist, or else a client for the Seerr instance's blocklist, rate limited as
+// configured
+func (cfg *Config) blocklistService() (BlocklistService, error) {
+	service := cfg.Blocklist
+	if service == nil {
+		seerrClient, err := seerrApi.NewClient(cfg.SeerrHost, cfg.SeerrApiKey)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.HTTPClient != nil {
+			seerrClient = seerrClient.WithHTTPClient(cfg.HTTPClient)
+		}
+		service = seerrClient.Blocklist()
+	}
+
+	if cfg.WriteRateLimit > 0 {
+		service = newRateLimited(service, cfg.WriteRateLimit, cfg.WriteBurst)
+	}
+	return service, nil
+}
+
+// Sync blocklists cfg's series on the Seerr instance and removes its allowlisted series from the blocklist
+func Sync(ctx context.Context, cfg Config) (Report, error) {
+	if len(cfg.UserIds) == 0 {
+		return Report{}, errors.New("no users to attribute blocklist entries to")
+	}
+
+	seerrBlocklistClient, err := cfg.blocklistService()
+	if err != nil {
+		return Report{}, err
+	}
+	if cfg.Series == nil && cfg.Source != nil {
+		if cfg.Series, err = cfg.Source.Series(ctx); err != nil {
+			return Report{}, err
+		}
+	}
+
+	s := &syncer{
+		cfg:                  &cfg,
+		seerrBlocklistClient: seerrBlocklistClient,
+		posted:               make(map[int]struct{}),
+		failures:             newFailures(),
+	}
+
+	if s.progress, err = loadCheckpoint(cfg.StateDir); err != nil {
+		return Report{}, err
+	}
+	if s.retries, err = loadRetryQueue(cfg.StateDir, cfg.RetryMaxAttempts); err != nil {
+		return Report{}, err
+	}
+	if s.added, err = loadAdditions(cfg.StateDir); err != nil {
+		return Report{}, err
+	}
+
+	// Saved by every finished sync, for CheckDrift, but only read by incremental ones
+	synced := &snapshot{filename: filepath.Join(cfg.StateDir, snapshotFile)}
+	if cfg.Incremental {
+		if synced, err = loadSnapshot(cfg.StateDir); err != nil {
+			return Report{}, err
+		}
+		if synced.upToDate(cfg.Series, s.retries, cfg.Allowlist) {
+			return Report{Finished: true, UpToDate: true}, nil
+		}
+	}
+
+	entries, partial, err := getBlocklist(ctx, seerrBlocklistClient)
+	if err != nil {
+		if err, ok := errors.AsType[*seerrApi.HTTPError](err); ok && err.StatusCode == http.StatusNotFound {
+			return Report{}, ErrNoBlocklist
+		}
+		return Report{}, err
+	}
+	s.blocklisted, s.report.Partial = tvTmdbIds(entries), partial
+	seerrBlocklistClient = &backupOnWrite{BlocklistService: seerrBlocklistClient, backup: func() error {
+		return saveBackup(cfg.StateDir, entries, partial)
+	}}
+	s.seerrBlocklistClient = seerrBlocklistClient
+
+	s.unblockAllowlisted(ctx)
+
+	s.conflicts = newConflictResolver(cfg.StateDir, seerrBlocklistClient, s.added, cfg.TmdbApiKey, s.report.Partial, cfg.Force, cfg.Verbose)
+	toAdd := s.retries.prepend(cfg.Series)
+	if cfg.Incremental {
+		toAdd = synced.changed(toAdd, s.retries)
+	}
+	stopped := false
+	if cfg.Approve != nil {
+		toAdd, stopped = s.approve(ctx, toAdd)
+	}
+	s.report.Finished = s.addToBlocklist(ctx, toAdd) && !stopped
+	s.report.Blocklisted = len(s.blocklisted)
+	s.report.Failures = s.failures.summary()
+	s.failures.log()
+
+	if cfg.Verify && ctx.Err() == nil {
+		if s.report.Missing, err = verifyBlocklisted(ctx, seerrBlocklistClient, toAdd, s.posted, s.retries); err != nil {
+			log.Printf("Error verifying blocklist: %v", err)
+		} else if s.report.Missing > 0 {
+			log.Printf("%d series missing from the blocklist, they'll be retried next run", s.report.Missing)
+		} else if cfg.Verbose {
+			fmt.Println("Verified blocklist")
+		}
+	}
+
+	if err = s.retries.save(); err != nil {
+		log.Printf("Error saving retry queue: %v", err)
+	}
+	if err = s.added.save(); err != nil {
+		log.Printf("Error saving added series: %v", err)
+	}
+	if s.report.Finished {
+		err = s.progress.finish()
+	} else {
+		err = s.progress.save()
+	}
+	if err != nil {
+		log.Printf("Error saving progress: %v", err)
+	}
+	if s.report.Finished {
+		if err = synced.save(s.landed(cfg.Series)); err != nil {
+			log.Printf("Error saving synced series: %v", err)
+		}
+	}
+
+	return s.report, nil
+}
+
+// landed returns the series on the blocklist after the sync, whether added by it or before, or kept out by a movie
+// with their ID
+func (s *syncer) landed(series []Series) []Series {
+	return slices.DeleteFunc(slices.Clone(series), func(p Series) bool {
+		_, ok := s.blocklisted[p.TmdbId]
+		return !ok
+	})
+}
+
+// failed records a failed change, stopping the sync once Config.FailFast have failed in a row
+func (s *syncer) failed(action, title string, tmdbId int, err error) {
+	s.failures.add(action, title, tmdbId, err)
+	s.consecutiveFailures++
+	if s.cfg.FailFast > 0 && s.consecutiveFailures >= s.cfg.FailFast && !s.report.Aborted {
+		log.Printf("Stopping after %d changes in a row failed", s.consecutiveFailures)
+		s.report.Aborted = true
+	}
+}
+
+// approve drops the series Config.Approve doesn't approve of from toAdd, out of those that would be added
+func (s *syncer) approve(ctx context.Context, toAdd []Series) ([]Series, bool) {
+	var pending []Series
+	for _, series := range toAdd {
+		if _, ok := s.blocklisted[series.TmdbId]; ok || series.TmdbId == 0 {
+			continue
+		}
+		if s.progress.isCompleted(series.TmdbId) && !s.retries.isQueued(series.TmdbId) {
+			continue
+		}
+		pending = append(pending, series)
+	}
+	if len(pending) == 0 {
+		return toAdd, false
+	}
+
+	// Before Approve, which may reuse pending for what it returns
+	rejected := make(map[int]struct{}, len(pending))
+	for _, series := range pending {
+		rejected[series.TmdbId] = struct{}{}
+	}
+	approved, stop := s.cfg.Approve(ctx, pending)
+	for _, series := range approved {
+		delete(rejected, series.TmdbId)
+	}
+
+	return slices.DeleteFunc(slices.Clone(toAdd), func(series Series) bool {
+		_, ok := rejected[series.TmdbId]
+		return ok
+	}), stop
+}
@@ -0,0 +1,376 @@
+package blocklistSync
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"anime-to-seerr-blocklist/internal/rest"
+	"anime-to-seerr-blocklist/internal/seerr"
+)
+
+// fakeBlocklist is a BlocklistService behaving like Seerr's blocklist, which refuses to add an ID already on it
+// whatever its media type, and failing to add the IDs in fail with their status code. With bulk, it has a bulk
+// endpoint, which adds a batch only if it could add every series in it
+type fakeBlocklist struct {
+	entries map[int]seerrApi.MediaType
+	fail    map[int]int
+	bulk    bool
+}
+
+func (f *fakeBlocklist) tmdbId(endpoint string) (int, error) {
+	return strconv.Atoi(strings.TrimPrefix(endpoint, "/"))
+}
+
+func (f *fakeBlocklist) Get(_ context.Context, endpoint string, _ url.Values, respBody any) error {
+	if endpoint == "" {
+		resp := respBody.(*seerrApi.GetBlocklistResponse)
+		for tmdbId, mediaType := range f.entries {
+			resp.Results = append(resp.Results, seerrApi.BlocklistEntry{TmdbId: tmdbId, MediaType: mediaType})
+		}
+		resp.PageInfo.Results = len(resp.Results)
+		return nil
+	}
+
+	tmdbId, err := f.tmdbId(endpoint)
+	if err != nil {
+		return err
+	}
+	mediaType, ok := f.entries[tmdbId]
+	if !ok {
+		return &restApi.HTTPError{StatusCode: http.StatusNotFound}
+	}
+	*respBody.(*seerrApi.GetBlocklistTmdbIdResponse) = seerrApi.GetBlocklistTmdbIdResponse{TmdbId: tmdbId, MediaType: mediaType}
+	return nil
+}
+
+func (f *fakeBlocklist) Post(ctx context.Context, endpoint string, queryParams url.Values, reqBody any, respBody any) error {
+	if endpoint == "/bulk" {
+		if !f.bulk {
+			return &restApi.HTTPError{StatusCode: http.StatusNotFound}
+		}
+		before := maps.Clone(f.entries)
+		for _, item := range reqBody.(*seerrApi.PostBlocklistBulkJSONRequestBody).Items {
+			if err := f.Post(ctx, "", queryParams, &item, respBody); err != nil {
+				f.entries = before
+				return err
+			}
+		}
+		return nil
+	}
+
+	body := reqBody.(*seerrApi.PostBlocklistJSONRequestBody)
+	if statusCode, ok := f.fail[body.TmdbId]; ok {
+		return &restApi.HTTPError{StatusCode: statusCode}
+	}
+	if _, ok := f.entries[body.TmdbId]; ok {
+		return &restApi.HTTPError{StatusCode: http.StatusPreconditionFailed}
+	}
+	f.entries[body.TmdbId] = body.MediaType
+	return nil
+}
+
+func (f *fakeBlocklist) Delete(_ context.Context, endpoint string, _ url.Values, _ any) error {
+	tmdbId, err := f.tmdbId(endpoint)
+	if err != nil {
+		return err
+	}
+	if _, ok := f.entries[tmdbId]; !ok {
+		return &restApi.HTTPError{StatusCode: http.StatusNotFound}
+	}
+	delete(f.entries, tmdbId)
+	return nil
+}
+
+func TestSync(t *testing.T) {
+	const tv, movie = seerrApi.MediaTypeTv, seerrApi.MediaTypeMovie
+
+	tests := []struct {
+		name string
+		// blocklist is the blocklist before the sync, and added the IDs earlier syncs recorded adding to it
+		blocklist map[int]seerrApi.MediaType
+		added     []int
+		series    []Series
+		allowlist map[int]struct{}
+		fail      map[int]int
+		bulk      bool
+		want      Report
+		// wantBlocklist is the blocklist after the sync, and wantSynced the series recorded as on it
+		wantBlocklist map[int]seerrApi.MediaType
+		wantSynced    []int
+	}{
+		{
+			name:          "added",
+			blocklist:     map[int]seerrApi.MediaType{},
+			series:        []Series{{TmdbId: 1, Title: "One"}, {TmdbId: 2, Title: "Two"}},
+			want:          Report{Added: 2, Blocklisted: 2, Finished: true},
+			wantBlocklist: map[int]seerrApi.MediaType{1: tv, 2: tv},
+			wantSynced:    []int{1, 2},
+		},
+		{
+			name:          "already blocklisted",
+			blocklist:     map[int]seerrApi.MediaType{1: tv},
+			series:        []Series{{TmdbId: 1, Title: "One"}, {TmdbId: 2, Title: "Two"}},
+			want:          Report{Added: 1, AlreadyBlocklisted: 1, Blocklisted: 2, Finished: true},
+			wantBlocklist: map[int]seerrApi.MediaType{1: tv, 2: tv},
+			wantSynced:    []int{1, 2},
+		},
+		{
+			name:          "conflict kept",
+			blocklist:     map[int]seerrApi.MediaType{1: movie},
+			series:        []Series{{TmdbId: 1, Title: "One"}},
+			want:          Report{Conflicts: 1, Blocklisted: 1, Finished: true},
+			wantBlocklist: map[int]seerrApi.MediaType{1: movie},
+			wantSynced:    []int{1},
+		},
+		{
+			name:          "conflict replaced",
+			blocklist:     map[int]seerrApi.MediaType{1: movie},
+			added:         []int{1},
+			series:        []Series{{TmdbId: 1, Title: "One"}},
+			want:          Report{Added: 1, Conflicts: 1, Blocklisted: 1, Finished: true},
+			wantBlocklist: map[int]seerrApi.MediaType{1: tv},
+			wantSynced:    []int{1},
+		},
+		{
+			name:          "added in bulk",
+			blocklist:     map[int]seerrApi.MediaType{3: tv},
+			series:        []Series{{TmdbId: 1, Title: "One"}, {TmdbId: 2, Title: "Two"}, {TmdbId: 3, Title: "Three"}},
+			bulk:          true,
+			want:          Report{Added: 2, AlreadyBlocklisted: 1, Blocklisted: 3, Finished: true},
+			wantBlocklist: map[int]seerrApi.MediaType{1: tv, 2: tv, 3: tv},
+			wantSynced:    []int{1, 2, 3},
+		},
+		{
+			name:          "failed batch added one at a time",
+			blocklist:     map[int]seerrApi.MediaType{1: movie},
+			series:        []Series{{TmdbId: 1, Title: "One"}, {TmdbId: 2, Title: "Two"}},
+			bulk:          true,
+			want:          Report{Added: 1, Conflicts: 1, Blocklisted: 2, Finished: true},
+			wantBlocklist: map[int]seerrApi.MediaType{1: movie, 2: tv},
+			wantSynced:    []int{1, 2},
+		},
+		{
+			name:          "failed not recorded as synced",
+			blocklist:     map[int]seerrApi.MediaType{},
+			series:        []Series{{TmdbId: 1, Title: "One"}, {TmdbId: 2, Title: "Two"}},
+			fail:          map[int]int{2: http.StatusBadRequest},
+			want:          Report{Added: 1, Failed: 1, Blocklisted: 1, Finished: true},
+			wantBlocklist: map[int]seerrApi.MediaType{1: tv},
+			wantSynced:    []int{1},
+		},
+		{
+			name:          "allowlisted removed",
+			blocklist:     map[int]seerrApi.MediaType{1: tv, 2: tv},
+			added:         []int{1, 2},
+			series:        []Series{{TmdbId: 2, Title: "Two"}},
+			allowlist:     map[int]struct{}{1: {}},
+			want:          Report{AlreadyBlocklisted: 1, Unblocked: 1, Blocklisted: 1, Finished: true},
+			wantBlocklist: map[int]seerrApi.MediaType{2: tv},
+			wantSynced:    []int{2},
+		},
+		{
+			name:          "allowlisted kept as not added by a sync",
+			blocklist:     map[int]seerrApi.MediaType{1: tv},
+			allowlist:     map[int]struct{}{1: {}},
+			want:          Report{Protected: 1, Blocklisted: 1, Finished: true},
+			wantBlocklist: map[int]seerrApi.MediaType{1: tv},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stateDir := t.TempDir()
+			added, err := loadAdditions(stateDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, tmdbId := range tt.added {
+				added.add(tmdbId, fmt.Sprint(tmdbId), 1)
+			}
+			if err = added.save(); err != nil {
+				t.Fatal(err)
+			}
+
+			blocklist := &fakeBlocklist{entries: maps.Clone(tt.blocklist), fail: tt.fail, bulk: tt.bulk}
+			report, err := Sync(t.Context(), Config{
+				UserIds:   []int{1},
+				Series:    tt.series,
+				Allowlist: tt.allowlist,
+				StateDir:  stateDir,
+				Verify:    true,
+				Bulk:      tt.bulk,
+				Blocklist: blocklist,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(report.Failures) != len(tt.fail) {
+				t.Errorf("failures = %+v", report.Failures)
+			}
+			report.Failures = nil
+			if !reflect.DeepEqual(report, tt.want) {
+				t.Errorf("report = %+v, want %+v", report, tt.want)
+			}
+			if !maps.Equal(blocklist.entries, tt.wantBlocklist) {
+				t.Errorf("blocklist = %v, want %v", blocklist.entries, tt.wantBlocklist)
+			}
+			// For CheckDrift, even though the sync isn't incremental
+			synced, err := loadSnapshot(stateDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := slices.Sorted(maps.Keys(synced.tmdbIds)); !slices.Equal(got, tt.wantSynced) {
+				t.Errorf("synced = %v, want %v", got, tt.wantSynced)
+			}
+		})
+	}
+}
+
+func TestCheckDrift(t *testing.T) {
+	const tv, movie = seerrApi.MediaTypeTv, seerrApi.MediaTypeMovie
+
+	tests := []struct {
+		name string
+		// added are the IDs earlier syncs recorded adding, and synced those the last one recorded as blocklisted
+		blocklist map[int]seerrApi.MediaType
+		added     []int
+		synced    []int
+		series    []Series
+		want      Drift
+	}{
+		{
+			name:      "none",
+			blocklist: map[int]seerrApi.MediaType{1: tv},
+			added:     []int{1},
+			synced:    []int{1},
+			series:    []Series{{TmdbId: 1, Title: "One"}},
+		},
+		{
+			name:      "removed",
+			blocklist: map[int]seerrApi.MediaType{},
+			added:     []int{1},
+			synced:    []int{1},
+			series:    []Series{{TmdbId: 1, Title: "One"}},
+			want:      Drift{Removed: []Series{{TmdbId: 1, Title: "One"}}},
+		},
+		{
+			name:      "missing",
+			blocklist: map[int]seerrApi.MediaType{},
+			synced:    []int{1},
+			series:    []Series{{TmdbId: 1, Title: "One"}},
+			want:      Drift{Missing: []Series{{TmdbId: 1, Title: "One"}}},
+		},
+		{
+			name:      "kept out by a movie",
+			blocklist: map[int]seerrApi.MediaType{1: movie},
+			synced:    []int{1},
+			series:    []Series{{TmdbId: 1, Title: "One"}},
+			want:      Drift{Conflicts: []Series{{TmdbId: 1, Title: "One"}}},
+		},
+		{
+			name:      "foreign",
+			blocklist: map[int]seerrApi.MediaType{1: tv, 2: tv, 3: movie},
+			series:    []Series{{TmdbId: 1, Title: "One"}},
+			want:      Drift{Foreign: []int{2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stateDir := t.TempDir()
+			added, err := loadAdditions(stateDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, tmdbId := range tt.added {
+				added.add(tmdbId, fmt.Sprint(tmdbId), 1)
+			}
+			if err = added.save(); err != nil {
+				t.Fatal(err)
+			}
+			synced := &snapshot{filename: filepath.Join(stateDir, snapshotFile)}
+			var syncedSeries []Series
+			for _, tmdbId := range tt.synced {
+				syncedSeries = append(syncedSeries, Series{TmdbId: tmdbId})
+			}
+			if err = synced.save(syncedSeries); err != nil {
+				t.Fatal(err)
+			}
+
+			drift, err := CheckDrift(t.Context(), Config{
+				Series:    tt.series,
+				StateDir:  stateDir,
+				Blocklist: &fakeBlocklist{entries: tt.blocklist},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(drift, tt.want) {
+				t.Errorf("drift = %+v, want %+v", drift, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyncIncremental(t *testing.T) {
+	series := []Series{{TmdbId: 1, Title: "One"}, {TmdbId: 2, Title: "Two"}, {TmdbId: 3, Title: "Three"}}
+
+	tests := []struct {
+		name string
+		// fail and reject are the IDs failing to be added and not approved by the first sync
+		fail   map[int]int
+		reject map[int]struct{}
+		// want is the report of the second sync, which neither fails nor rejects any
+		want Report
+	}{
+		{"all landed", nil, nil, Report{Finished: true, UpToDate: true}},
+		{"failed", map[int]int{2: http.StatusBadRequest}, nil, Report{Added: 1, Blocklisted: 3, Finished: true}},
+		{"rejected", nil, map[int]struct{}{3: {}}, Report{Added: 1, Blocklisted: 3, Finished: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stateDir := t.TempDir()
+			blocklist := &fakeBlocklist{entries: map[int]seerrApi.MediaType{}, fail: tt.fail}
+			cfg := Config{
+				UserIds:     []int{1},
+				Series:      series,
+				StateDir:    stateDir,
+				Incremental: true,
+				Blocklist:   blocklist,
+				Approve: func(_ context.Context, pending []Series) ([]Series, bool) {
+					return slices.DeleteFunc(pending, func(p Series) bool {
+						_, ok := tt.reject[p.TmdbId]
+						return ok
+					}), false
+				},
+			}
+			if _, err := Sync(t.Context(), cfg); err != nil {
+				t.Fatal(err)
+			}
+
+			blocklist.fail, tt.reject = nil, nil
+			report, err := Sync(t.Context(), cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			report.Failures = nil
+			if !reflect.DeepEqual(report, tt.want) {
+				t.Errorf("report = %+v, want %+v", report, tt.want)
+			}
+			if len(blocklist.entries) != len(series) {
+				t.Errorf("blocklist = %v, want all of %v", blocklist.entries, series)
+			}
+		})
+	}
+}
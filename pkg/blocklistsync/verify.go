@@ -0,0 +1,35 @@
+package blocklistSync
+
+import (
+	"context"
+	"errors"
+	"log"
+)
+
+var errNotBlocklisted = errors.New("missing from the blocklist after being added")
+
+// verifyBlocklisted fetches the blocklist again and reports the series of toAdd that were posted to it this run but
+// aren't on it, queueing them to be retried. It returns how many are missing. Conflicts that were kept are left out,
+// as the entry blocking their ID may be for a movie, which isn't on the TV blocklist fetched
+func verifyBlocklisted(ctx context.Context, seerrBlocklistClient BlocklistService, toAdd []Series, posted map[int]struct{}, retries *retryQueue) (int, error) {
+	fresh, partial, err := getAlreadyBlocklisted(ctx, seerrBlocklistClient)
+	if err != nil {
+		return 0, err
+	}
+	if partial {
+		return 0, errors.New("blocklist couldn't be fetched in full")
+	}
+
+	missing := 0
+	for _, p := range toAdd {
+		if _, ok := posted[p.TmdbId]; !ok || p.TmdbId == 0 {
+			continue
+		}
+		if _, ok := fresh[p.TmdbId]; !ok {
+			log.Printf("Error verifying %s (%v): %v", p.Title, p.TmdbId, errNotBlocklisted)
+			retries.failed(p.TmdbId, p.Title, errNotBlocklisted)
+			missing++
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"anime-to-seerr-blocklist/internal/console"
+	"anime-to-seerr-blocklist/internal/plex"
+)
+
+// addPlexWatchlist allowlists the series on the Plex account's watchlist
+func addPlexWatchlist(ctx context.Context, plexLibraryClient *plexApi.Client, allowlist map[int]struct{}, verbose bool) error {
+	const size = 100
+
+	values := url.Values{
+		"X-Plex-Container-Start": []string{""},
+		"X-Plex-Container-Size":  []string{strconv.Itoa(size)},
+	}
+
+	for start := 0; ; start += size {
+		var resp plexApi.MediaContainerResponse
+		values["X-Plex-Container-Start"][0] = strconv.Itoa(start)
+
+		if err := plexLibraryClient.Get(ctx, "/sections/watchlist/all", values, &resp); err != nil {
+			return err
+		}
+
+		for _, item := range resp.MediaContainer.Metadata {
+			if item.Type != "show" {
+				continue
+			}
+
+			// The watchlist doesn't include external IDs
+			var metadata plexApi.MediaContainerResponse
+			if err := plexLibraryClient.Get(ctx, "/metadata/"+item.RatingKey, nil, &metadata); err != nil {
+				log.Printf("Error getting Plex metadata of %s: %v", item.Title, err)
+				continue
+			}
+
+			for _, m := range metadata.MediaContainer.Metadata {
+				for _, guid := range m.Guid {
+					if tmdbId, ok := strings.CutPrefix(guid.Id, "tmdb://"); ok {
+						if id, err := strconv.Atoi(tmdbId); err == nil {
+							if verbose {
+								console.Skipped("Allowlisting %s (%v) from Plex watchlist\n", item.Title, id)
+							}
+							allowlist[id] = struct{}{}
+						}
+					}
+				}
+			}
+		}
+
+		if len(resp.MediaContainer.Metadata) == 0 || start+size >= resp.MediaContainer.TotalSize {
+			break
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"anime-to-seerr-blocklist/internal/anime-list"
+	"anime-to-seerr-blocklist/internal/console"
+)
+
+// allowlistPopular allowlists series whose TMDB vote count or popularity score is above threshold, keeping the biggest
+// hits requestable
+func allowlistPopular(ctx context.Context, fdp []AnimeList.Anime, tmdbCache *tmdbTvCache, metric string, threshold float64, allowlist map[int]struct{}, verbose bool) {
+	for _, p := range uniqueSeries(fdp) {
+		details, err := tmdbCache.get(ctx, p.Tmdbtv)
+		if err != nil {
+			log.Printf("Error getting popularity of %s (%v): %v", p.Name, p.Tmdbtv, err)
+			continue
+		}
+
+		value := details.Popularity
+		if metric == "votes" {
+			value = float64(details.VoteCount)
+		}
+		if value <= threshold {
+			continue
+		}
+
+		if verbose {
+			console.Skipped("Allowlisting popular %s (%v)\n", p.Name, p.Tmdbtv)
+		}
+		allowlist[p.Tmdbtv] = struct{}{}
+	}
+}
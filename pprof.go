@@ -0,0 +1,31 @@
+package main
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"net/http/pprof"
+)
+
+// startPprof serves the runtime profiles at addr under /debug/pprof/ until the process exits
+func startPprof(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	log.Printf("Serving profiles at http://%s/debug/pprof/", l.Addr())
+	go func() {
+		if err := http.Serve(l, mux); err != nil {
+			log.Printf("Error serving profiles: %v", err)
+		}
+	}()
+	return nil
+}
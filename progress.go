@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// progressBar draws a sync's progress on a terminal, with the rate and an estimate of the time left
+type progressBar struct {
+	w       io.Writer
+	start   time.Time
+	drawn   time.Time
+	started bool
+}
+
+const progressBarWidth = 30
+
+func (b *progressBar) update(done, total int) {
+	now := time.Now()
+	if !b.started {
+		b.start = now
+		b.started = true
+	}
+	// Redrawing for every series would only slow the sync down
+	if done < total && now.Sub(b.drawn) < 200*time.Millisecond {
+		return
+	}
+	b.drawn = now
+
+	filled := 0
+	if total > 0 {
+		filled = progressBarWidth * done / total
+	}
+	line := fmt.Sprintf("[%s%s] %d/%d", strings.Repeat("=", filled), strings.Repeat(" ", progressBarWidth-filled), done, total)
+
+	if elapsed := now.Sub(b.start); done > 0 && elapsed > 0 {
+		rate := float64(done) / elapsed.Seconds()
+		eta := time.Duration(float64(total-done) / rate * float64(time.Second)).Round(time.Second)
+		line += fmt.Sprintf(" %.1f/s ETA %v", rate, eta)
+	}
+
+	// Clear the rest of the line in case it was longer before
+	fmt.Fprintf(b.w, "\r%s\x1b[K", line)
+	if done == total {
+		fmt.Fprintln(b.w)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"anime-to-seerr-blocklist/internal/anime-list"
+	"anime-to-seerr-blocklist/internal/arr"
+	"anime-to-seerr-blocklist/internal/console"
+)
+
+// movieTmdbIds returns the TMDB movie IDs of p, of which there can be several for entries like compilation films
+func movieTmdbIds(p *AnimeList.Anime) []int {
+	var ids []int
+	for field := range strings.SplitSeq(p.Tmdbid, ",") {
+		if id, err := strconv.Atoi(strings.TrimSpace(field)); err == nil && id > 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// addRadarrExclusions adds every mapped TMDB movie to Radarr's list exclusions in a single bulk request
+func addRadarrExclusions(ctx context.Context, radarrExclusionClient *arrApi.Client, fdp []AnimeList.Anime, verbose bool) error {
+	var existing []arrApi.Exclusion
+	if err := radarrExclusionClient.Get(ctx, "", nil, &existing); err != nil {
+		return err
+	}
+
+	excluded := make(map[int]struct{}, len(existing))
+	for _, exclusion := range existing {
+		excluded[exclusion.TmdbId] = struct{}{}
+	}
+
+	var exclusions []arrApi.Exclusion
+	for i := range fdp {
+		for _, tmdbId := range movieTmdbIds(&fdp[i]) {
+			if _, ok := excluded[tmdbId]; ok {
+				continue
+			}
+			excluded[tmdbId] = struct{}{}
+
+			if verbose {
+				console.Added("Excluding %s (%v) in Radarr\n", fdp[i].Name, tmdbId)
+			}
+			exclusions = append(exclusions, arrApi.Exclusion{TmdbId: tmdbId, MovieTitle: fdp[i].Name})
+		}
+	}
+
+	if len(exclusions) == 0 {
+		return nil
+	}
+	return radarrExclusionClient.Post(ctx, "/bulk", nil, exclusions, nil)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"anime-to-seerr-blocklist/pkg/blocklistsync"
+)
+
+func runRepair(ctx context.Context, opts *options, args []string) {
+	var list, yes bool
+
+	fs := flag.NewFlagSet("repair", flag.ExitOnError)
+	fs.BoolVar(&list, "list", false, "List the movies that may have been replaced instead of restoring any")
+	fs.BoolVar(&yes, "yes", false, "Restore every movie -list would list without asking")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: anime-to-seerr-blocklist repair [-list | -yes]")
+		fmt.Fprintln(fs.Output(), "Offers to put back on the blocklist the movies that series added by syncs share their TMDB ID with, as")
+		fmt.Fprintln(fs.Output(), "conflicts may have replaced them. Requires $TMDB_API_KEY to look the movies up")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	seerrHost := os.Getenv("SEERR_HOST")
+	seerrApiKey := os.Getenv("SEERR_API_KEY")
+	tmdbApiKey := os.Getenv("TMDB_API_KEY")
+	if seerrHost == "" || seerrApiKey == "" || tmdbApiKey == "" {
+		log.Fatal("$SEERR_HOST/$SEERR_API_KEY/$TMDB_API_KEY are required")
+	}
+	seerrUserIds, _ := parseIds(os.Getenv("SEERR_USER_ID"))
+	cfg := blocklistSync.Config{
+		SeerrHost:      seerrHost,
+		SeerrApiKey:    seerrApiKey,
+		UserIds:        seerrUserIds,
+		StateDir:       opts.cacheDir,
+		TmdbApiKey:     tmdbApiKey,
+		WriteRateLimit: opts.rateLimit,
+		WriteBurst:     opts.rateBurst,
+		Force:          opts.force,
+		Verbose:        opts.verbose,
+	}
+
+	// Found from the blocklist, so those restored before aren't asked about again
+	movies, err := blocklistSync.DamagedEntries(ctx, cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if list {
+		for _, movie := range movies {
+			fmt.Println(describeReplaced(movie))
+		}
+		return
+	}
+	if len(movies) == 0 {
+		fmt.Println("No series added by syncs share their TMDB ID with a movie")
+		return
+	}
+
+	if !yes {
+		scanner := bufio.NewScanner(os.Stdin)
+		approved := movies[:0:0]
+	ask:
+		for i, movie := range movies {
+			fmt.Println(describeReplaced(movie))
+			for {
+				fmt.Print("Restore the movie, removing the series? [y]es, [n]o, [a]ll remaining, [q]uit: ")
+				if !scanner.Scan() {
+					fmt.Println()
+					break ask
+				}
+
+				switch strings.ToLower(strings.TrimSpace(scanner.Text())) {
+				case "y", "yes":
+					approved = append(approved, movie)
+				case "n", "no":
+				case "a", "all":
+					approved = append(approved, movies[i:]...)
+					break ask
+				case "q", "quit":
+					break ask
+				default:
+					continue
+				}
+				break
+			}
+		}
+		movies = approved
+	}
+
+	report, err := blocklistSync.Repair(ctx, cfg, movies)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Restored %d movies, %d already were, %d failed\n", report.Restored, report.AlreadyRestored, report.Failed)
+	if report.Kept > 0 {
+		fmt.Printf("Kept %d series in their place that syncs didn't add, -force removes them too\n", report.Kept)
+	}
+}
+
+// describeReplaced says why movie may need restoring
+func describeReplaced(movie blocklistSync.ReplacedMovie) string {
+	if movie.Logged {
+		return fmt.Sprintf("%s (%v) was replaced by the series %s on %s", movie.Title, movie.TmdbId, movie.SeriesTitle, movie.Time.Local().Format("2006-01-02 15:04:05"))
+	}
+	return fmt.Sprintf("%s (%v) shares its TMDB ID with the series %s, added on %s", movie.Title, movie.TmdbId, movie.SeriesTitle, movie.Time.Local().Format("2006-01-02 15:04:05"))
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"anime-to-seerr-blocklist/pkg/blocklistsync"
+)
+
+func runRollback(ctx context.Context, opts *options, args []string) {
+	var list bool
+
+	fs := flag.NewFlagSet("rollback", flag.ExitOnError)
+	fs.BoolVar(&list, "list", false, "List the backups instead of restoring one")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: anime-to-seerr-blocklist rollback [-list] [backup]")
+		fmt.Fprintln(fs.Output(), "Restores the blocklist to a backup taken before a sync changed it, by default the latest")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	backups, err := blocklistSync.Backups(opts.cacheDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if list {
+		for _, backup := range backups {
+			fmt.Println(filepath.Base(backup))
+		}
+		return
+	}
+
+	name := fs.Arg(0)
+	if name == "" {
+		if len(backups) == 0 {
+			log.Fatal("no backups to roll back to")
+		}
+		name = filepath.Base(backups[len(backups)-1])
+	}
+	backup, err := blocklistSync.LoadBackup(opts.cacheDir, name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	seerrHost := os.Getenv("SEERR_HOST")
+	seerrApiKey := os.Getenv("SEERR_API_KEY")
+	if seerrHost == "" || seerrApiKey == "" {
+		log.Fatal("$SEERR_HOST/$SEERR_API_KEY are required")
+	}
+	seerrUserIds, _ := parseIds(os.Getenv("SEERR_USER_ID"))
+
+	log.Printf("Rolling back the blocklist to %s", backup.Time.Local().Format("2006-01-02 15:04:05"))
+	if backup.Partial {
+		log.Print("The backup is partial, so entries added since won't be removed")
+	}
+	report, err := blocklistSync.Rollback(ctx, blocklistSync.Config{
+		SeerrHost:      seerrHost,
+		SeerrApiKey:    seerrApiKey,
+		UserIds:        seerrUserIds,
+		StateDir:       opts.cacheDir,
+		WriteRateLimit: opts.rateLimit,
+		WriteBurst:     opts.rateBurst,
+		Force:          opts.force,
+		Verbose:        opts.verbose,
+	}, backup)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Restored %d entries, removed %d, %d failed\n", report.Restored, report.Removed, report.Failed)
+	if report.Kept > 0 {
+		fmt.Printf("Kept %d entries added since that syncs didn't add, -force removes them too\n", report.Kept)
+	}
+}
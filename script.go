@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"anime-to-seerr-blocklist/internal/seerr"
+)
+
+// scriptWriter stands in for Seerr's blocklist during a sync, passing reads through to it but writing the changes the
+// sync makes as commands of a shell script for the admin to review and run instead
+type scriptWriter struct {
+	*seerrApi.Client
+	w      io.Writer
+	format string
+	err    error
+}
+
+func newScriptWriter(w io.Writer, format string, seerrBlocklistClient *seerrApi.Client) (*scriptWriter, error) {
+	if format != "curl" && format != "httpie" {
+		return nil, fmt.Errorf("unknown script format %q, expected curl or httpie", format)
+	}
+
+	s := &scriptWriter{Client: seerrBlocklistClient, w: w, format: format}
+	s.printf("#!/bin/sh\n")
+	s.printf("# Blocklist changes for %s, generated by anime-to-seerr-blocklist at %s\n", shellQuote(seerrBlocklistClient.URL().String()), time.Now().Format(time.RFC3339))
+	s.printf("# Run with $SEERR_API_KEY set. Any headers needed to get past a reverse proxy must be added by hand\n")
+	s.printf("# A series whose TMDB ID is blocklisted as a movie fails to be added, which a normal sync would replace\n")
+	s.printf(": \"${SEERR_API_KEY:?is required}\"\n")
+	s.printf("set -e\n\n")
+	return s, s.err
+}
+
+func (s *scriptWriter) printf(format string, a ...any) {
+	if s.err == nil {
+		_, s.err = fmt.Fprintf(s.w, format, a...)
+	}
+}
+
+// shellQuote quotes s as a single word for sh
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func (s *scriptWriter) command(method, endpoint string, queryParams url.Values, reqBody any) error {
+	u := s.URL()
+	if endpoint != "" {
+		u = u.JoinPath(endpoint)
+	}
+	u.RawQuery = queryParams.Encode()
+
+	var body string
+	if reqBody != nil {
+		b, err := json.Marshal(reqBody)
+		if err != nil {
+			return err
+		}
+		body = string(b)
+	}
+
+	switch s.format {
+	case "curl":
+		s.printf("curl -fsS -X %s -H \"X-Api-Key: $SEERR_API_KEY\"", method)
+		if body != "" {
+			s.printf(" -H 'Content-Type: application/json' --data %s", shellQuote(body))
+		}
+		s.printf(" %s\n", shellQuote(u.String()))
+	case "httpie":
+		s.printf("http --check-status --ignore-stdin %s %s \"X-Api-Key:$SEERR_API_KEY\"", method, shellQuote(u.String()))
+		if body != "" {
+			s.printf(" --raw %s", shellQuote(body))
+		}
+		s.printf("\n")
+	}
+	return s.err
+}
+
+func (s *scriptWriter) Post(_ context.Context, endpoint string, queryParams url.Values, reqBody any, _ any) error {
+	return s.command(http.MethodPost, endpoint, queryParams, reqBody)
+}
+
+func (s *scriptWriter) Delete(_ context.Context, endpoint string, queryParams url.Values, reqBody any) error {
+	return s.command(http.MethodDelete, endpoint, queryParams, reqBody)
+}
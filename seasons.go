@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strconv"
+
+	"anime-to-seerr-blocklist/internal/anime-list"
+	"anime-to-seerr-blocklist/internal/console"
+)
+
+// dropMixedSeries drops series from the mapping that have regular seasons on TMDB which no AniDB entry maps to, such as
+// western shows with a single anime season
+func dropMixedSeries(ctx context.Context, fdp []AnimeList.Anime, tmdbCache *tmdbTvCache, verbose bool) []AnimeList.Anime {
+	// Series mapped with absolute numbering or without any season information are assumed to be entirely anime
+	wholeSeries := make(map[int]struct{})
+	mappedSeasons := make(map[int]map[int]struct{})
+
+	for _, p := range fdp {
+		if p.Tmdbtv == 0 {
+			continue
+		}
+
+		season, err := strconv.Atoi(p.Tmdbseason)
+		if err != nil {
+			wholeSeries[p.Tmdbtv] = struct{}{}
+			continue
+		}
+
+		seasons := mappedSeasons[p.Tmdbtv]
+		if seasons == nil {
+			seasons = make(map[int]struct{})
+			mappedSeasons[p.Tmdbtv] = seasons
+		}
+		seasons[season] = struct{}{}
+		if p.MappingList != nil {
+			for _, mapping := range p.MappingList.Mapping {
+				if mapping.Tmdbseason != nil {
+					seasons[*mapping.Tmdbseason] = struct{}{}
+				}
+			}
+		}
+	}
+
+	mixed := make(map[int]struct{})
+	for tmdbId, seasons := range mappedSeasons {
+		if _, ok := wholeSeries[tmdbId]; ok {
+			continue
+		}
+
+		details, err := tmdbCache.get(ctx, tmdbId)
+		if err != nil {
+			log.Printf("Error getting seasons of %v: %v", tmdbId, err)
+			continue
+		}
+
+		for _, season := range details.Seasons {
+			if _, ok := seasons[season]; !ok && season > 0 {
+				mixed[tmdbId] = struct{}{}
+				break
+			}
+		}
+	}
+
+	return slices.DeleteFunc(fdp, func(p AnimeList.Anime) bool {
+		_, ok := mixed[p.Tmdbtv]
+		if ok && verbose {
+			console.Skipped("Skipping %s (%v) as not all of its seasons are anime\n", p.Name, p.Tmdbtv)
+		}
+		return ok
+	})
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"aead.dev/minisign"
+
+	"anime-to-seerr-blocklist/internal/rest"
+)
+
+const releasesURL = "https://api.github.com/repos/qwerty12/anime-to-seerr-blocklist/releases/latest"
+
+// The minisign public key that signs releases' checksums.txt, set with -ldflags "-X main.releasePublicKey=..." by
+// release builds. Without it, self-update refuses to install anything
+var releasePublicKey = ""
+
+type githubAsset struct {
+	Name               string `json:"name"`
+	BrowserDownloadUrl string `json:"browser_download_url"`
+}
+
+type githubRelease struct {
+	TagName string        `json:"tag_name"`
+	Assets  []githubAsset `json:"assets"`
+}
+
+// assetUrl returns the download URL of the release's asset called name
+func (r *githubRelease) assetUrl(name string) (string, bool) {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset.BrowserDownloadUrl, true
+		}
+	}
+	return "", false
+}
+
+// getGithub GETs rawUrl, returning the response body for the caller to close
+func getGithub(ctx context.Context, rawUrl string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", restApi.UserAgent)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status fetching %s: %s", rawUrl, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// readGithub GETs rawUrl, returning the whole response body
+func readGithub(ctx context.Context, rawUrl string) ([]byte, error) {
+	body, err := getGithub(ctx, rawUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(restApi.LimitReader(body, restApi.MaxResponseSize))
+}
+
+// verifiedChecksums fetches the release's checksums file, returning it only if its minisign signature was made by
+// the release key
+func verifiedChecksums(ctx context.Context, release *githubRelease, publicKey minisign.PublicKey) ([]byte, error) {
+	checksumsUrl, ok := release.assetUrl("checksums.txt")
+	if !ok {
+		return nil, errors.New("no checksums.txt to verify the download against")
+	}
+	signatureUrl, ok := release.assetUrl("checksums.txt.minisig")
+	if !ok {
+		return nil, errors.New("no checksums.txt.minisig to verify checksums.txt against")
+	}
+
+	checksums, err := readGithub(ctx, checksumsUrl)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := readGithub(ctx, signatureUrl)
+	if err != nil {
+		return nil, err
+	}
+	if !minisign.Verify(publicKey, checksums, signature) {
+		return nil, errors.New("checksums.txt is not signed by the release key")
+	}
+	return checksums, nil
+}
+
+// releaseChecksum finds name's SHA-256 in a release's checksums file, in the format written by sha256sum
+func releaseChecksum(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		sum, file, ok := strings.Cut(scanner.Text(), " ")
+		if ok && strings.TrimLeft(file, " *") == name {
+			return strings.ToLower(sum), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no checksum for %s", name)
+}
+
+// replaceExecutable downloads binaryUrl next to exe, verifies it against wantSum and moves it over exe
+func replaceExecutable(ctx context.Context, exe, binaryUrl, wantSum string) (err error) {
+	body, err := getGithub(ctx, binaryUrl)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	// Same directory, so the rename can't cross filesystems
+	f, err := os.CreateTemp(filepath.Dir(exe), filepath.Base(exe))
+	if err != nil {
+		return fmt.Errorf("cannot create temp file: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(f.Name())
+		}
+	}()
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err = io.Copy(io.MultiWriter(f, h), restApi.LimitReader(body, maxDownloadSize)); err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != wantSum {
+		return fmt.Errorf("checksum mismatch: got %s, want %s", got, wantSum)
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	fi, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+	if err = os.Chmod(f.Name(), fi.Mode()); err != nil {
+		return err
+	}
+	return replaceRunning(f.Name(), exe)
+}
+
+func runSelfUpdate(ctx context.Context, args []string) {
+	var check, force bool
+
+	fs := flag.NewFlagSet("self-update", flag.ExitOnError)
+	fs.BoolVar(&check, "check", false, "Only report whether a newer release is available")
+	fs.BoolVar(&force, "force", false, "Install the latest release even if it's the running version")
+	_ = fs.Parse(args)
+
+	var publicKey minisign.PublicKey
+	if !check {
+		if releasePublicKey == "" {
+			log.Fatal("This build has no release key to verify updates with, download the release from " + repoURL + " instead")
+		}
+		if err := publicKey.UnmarshalText([]byte(releasePublicKey)); err != nil {
+			log.Fatalf("Invalid release key: %v", err)
+		}
+	}
+
+	body, err := getGithub(ctx, releasesURL)
+	if err != nil {
+		log.Fatalf("Error checking for releases: %v", err)
+	}
+	var release githubRelease
+	err = json.NewDecoder(restApi.LimitReader(body, restApi.MaxResponseSize)).Decode(&release)
+	body.Close()
+	if err != nil {
+		log.Fatalf("Error checking for releases: %v", err)
+	}
+
+	if release.TagName == version && !force {
+		fmt.Printf("Already up to date (%s)\n", version)
+		return
+	}
+	if check {
+		fmt.Printf("%s is available\n", release.TagName)
+		return
+	}
+
+	name := fmt.Sprintf("anime-to-seerr-blocklist_%s_%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	binaryUrl, ok := release.assetUrl(name)
+	if !ok {
+		log.Fatalf("Release %s has no build for %s/%s", release.TagName, runtime.GOOS, runtime.GOARCH)
+	}
+	checksums, err := verifiedChecksums(ctx, &release, publicKey)
+	if err != nil {
+		log.Fatalf("Error verifying release %s: %v", release.TagName, err)
+	}
+	wantSum, err := releaseChecksum(checksums, name)
+	if err != nil {
+		log.Fatalf("Error verifying release %s: %v", release.TagName, err)
+	}
+
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = replaceExecutable(ctx, exe, binaryUrl, wantSum); err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			log.Fatalf("Error replacing %s, try again as its owner: %v", exe, err)
+		}
+		log.Fatalf("Error updating: %v", err)
+	}
+	fmt.Printf("Updated to %s\n", release.TagName)
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package main
+
+import "codeberg.org/sdassow/atomic"
+
+// replaceRunning moves the new binary at path over exe, which running processes keep open unaffected
+func replaceRunning(path, exe string) error {
+	return atomic.ReplaceFile(path, exe)
+}
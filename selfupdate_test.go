@@ -0,0 +1,73 @@
+package main
+
+import (
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"aead.dev/minisign"
+)
+
+func TestVerifiedChecksums(t *testing.T) {
+	publicKey, privateKey, err := minisign.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherKey, err := minisign.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checksums := []byte("0123abcd  anime-to-seerr-blocklist_linux_amd64\n4567EF01 *anime-to-seerr-blocklist_windows_amd64.exe\n")
+	tests := []struct {
+		name      string
+		served    []byte
+		signature []byte
+		wantErr   bool
+	}{
+		{"signed", checksums, minisign.Sign(privateKey, checksums), false},
+		{"signed by another key", checksums, minisign.Sign(otherKey, checksums), true},
+		{"tampered", append([]byte("ffff  anime-to-seerr-blocklist_linux_arm64\n"), checksums...), minisign.Sign(privateKey, checksums), true},
+		{"unsigned", checksums, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /checksums.txt", func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write(tt.served)
+			})
+			mux.HandleFunc("GET /checksums.txt.minisig", func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write(tt.signature)
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			release := githubRelease{Assets: []githubAsset{{"checksums.txt", server.URL + "/checksums.txt"}}}
+			if tt.signature != nil {
+				release.Assets = append(release.Assets, githubAsset{"checksums.txt.minisig", server.URL + "/checksums.txt.minisig"})
+			}
+
+			got, err := verifiedChecksums(t.Context(), &release, publicKey)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("verifiedChecksums() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			for name, want := range map[string]string{
+				"anime-to-seerr-blocklist_linux_amd64":       "0123abcd",
+				"anime-to-seerr-blocklist_windows_amd64.exe": "4567ef01",
+			} {
+				if sum, err := releaseChecksum(got, name); err != nil || sum != want {
+					t.Errorf("releaseChecksum(%s) = %s, %v, want %s", name, sum, err, want)
+				}
+			}
+			if _, err = releaseChecksum(got, "anime-to-seerr-blocklist_linux_arm64"); err == nil {
+				t.Error("releaseChecksum() found a checksum for a missing build")
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	"codeberg.org/sdassow/atomic"
+)
+
+// replaceRunning moves the new binary at path over exe. Windows won't replace or delete a running executable but
+// will rename it, so exe is moved aside to exe.old first, to be removed by the next update
+func replaceRunning(path, exe string) error {
+	old := exe + ".old"
+	// Left by the last update, and no longer running unless that one's process still is
+	if err := os.Remove(old); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.Rename(exe, old); err != nil {
+		return err
+	}
+	if err := atomic.ReplaceFile(path, exe); err != nil {
+		_ = os.Rename(old, exe)
+		return err
+	}
+	// Succeeds only if exe wasn't running, as when updated from another copy
+	_ = os.Remove(old)
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"log"
+)
+
+// Elsewhere, -daemon is run by systemd, launchd and the like, which need no help from the daemon itself
+func runService(context.Context, *options, []string, []string, []string, string) {
+	log.Fatal("service is only supported on Windows, run -daemon under your init system instead")
+}
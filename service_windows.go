@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+const serviceName = "anime-to-seerr-blocklist"
+
+// runService installs or uninstalls the Windows service that runs the daemon at boot with the flags args, or is the
+// service when started by the service control manager
+func runService(ctx context.Context, opts *options, args, flagArgs, environ []string, exeDir string) {
+	usage := "usage: anime-to-seerr-blocklist [flags] service install|uninstall|run"
+	if len(args) != 1 {
+		log.Fatal(usage)
+	}
+
+	var err error
+	switch args[0] {
+	case "install":
+		err = installService(opts, flagArgs)
+	case "uninstall":
+		err = uninstallService()
+	case "run":
+		err = runAsService(ctx, opts, flagArgs, environ, exeDir)
+	default:
+		log.Fatal(usage)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// installService installs the service to run the daemon with flagArgs. It's a daemon whatever the flags, and starts in
+// System32, so the cache directory and any .env file are passed as absolute paths
+func installService(opts *options, flagArgs []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if exe, err = filepath.Abs(exe); err != nil {
+		return err
+	}
+
+	// After flagArgs, to take precedence over them
+	args := append(slices.Clone(flagArgs), "-daemon")
+	cacheDir, err := filepath.Abs(opts.cacheDir)
+	if err != nil {
+		return err
+	}
+	args = append(args, "-cache-dir", cacheDir)
+	if opts.envFile != "" {
+		envFile, err := filepath.Abs(opts.envFile)
+		if err != nil {
+			return err
+		}
+		args = append(args, "-env-file", envFile)
+	}
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+
+	s, err := m.CreateService(serviceName, exe, mgr.Config{
+		DisplayName: "Anime to Seerr blocklist",
+		Description: "Keeps anime on Seerr's blocklist, syncing on a schedule",
+		StartType:   mgr.StartAutomatic,
+		// Syncs need the network, which may not be up yet at boot
+		DelayedAutoStart: true,
+	}, append(args, "service", "run")...)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	// Restart after a minute should the daemon die
+	err = s.SetRecoveryActions([]mgr.RecoveryAction{
+		{Type: mgr.ServiceRestart, Delay: time.Minute},
+	}, uint32((24 * time.Hour).Seconds()))
+	if err != nil {
+		log.Printf("Error setting the service to restart on failure: %v", err)
+	}
+
+	fmt.Printf("Installed the %s service, which starts at boot. Start it now with: sc start %s\n", serviceName, serviceName)
+	return nil
+}
+
+func uninstallService() error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(serviceName)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	if _, err = s.Control(svc.Stop); err != nil && !errors.Is(err, windows.ERROR_SERVICE_NOT_ACTIVE) {
+		log.Printf("Error stopping the service: %v", err)
+	}
+	if err = s.Delete(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Uninstalled the %s service\n", serviceName)
+	return nil
+}
+
+// serviceHandler runs the daemon until the service control manager stops it
+type serviceHandler struct {
+	run func(ctx context.Context)
+}
+
+func (h *serviceHandler) Execute(_ []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.run(ctx)
+	}()
+
+	const accepts = svc.AcceptStop | svc.AcceptShutdown
+	status <- svc.Status{State: svc.Running, Accepts: accepts}
+	for {
+		select {
+		case <-done:
+			cancel()
+			return false, 0
+		case request := <-requests:
+			switch request.Cmd {
+			case svc.Interrogate:
+				status <- request.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				// Stopping the daemon interrupts the current sync, which may take a while to save its progress
+				status <- svc.Status{State: svc.StopPending, WaitHint: uint32(time.Minute.Milliseconds())}
+				cancel()
+				<-done
+				return false, 0
+			default:
+				status <- svc.Status{State: svc.Running, Accepts: accepts}
+			}
+		}
+	}
+}
+
+func runAsService(ctx context.Context, opts *options, flagArgs, environ []string, exeDir string) error {
+	isService, err := svc.IsWindowsService()
+	if err != nil {
+		return err
+	}
+	if !isService {
+		return errors.New("service run is for the service control manager, run with -daemon instead")
+	}
+
+	// Services have no console, so the output of the daemon and its syncs goes to a log file instead
+	if err = os.MkdirAll(opts.cacheDir, 0o755); err != nil {
+		return err
+	}
+	logFile, err := os.OpenFile(filepath.Join(opts.cacheDir, "service.log"), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+	os.Stdout, os.Stderr = logFile, logFile
+	log.SetOutput(logFile)
+
+	return svc.Run(serviceName, &serviceHandler{run: func(serviceCtx context.Context) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		context.AfterFunc(serviceCtx, cancel)
+		runDaemon(ctx, opts, flagArgs, environ, exeDir)
+	}})
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"strconv"
+
+	"anime-to-seerr-blocklist/internal/anime-list"
+	"anime-to-seerr-blocklist/internal/console"
+	"anime-to-seerr-blocklist/internal/shoko"
+)
+
+// fetchShokoSeries returns the anime in Shoko Server's collection as mapping entries, with the TVDB and TMDB series
+// Shoko has linked them to
+func fetchShokoSeries(ctx context.Context) ([]AnimeList.Anime, error) {
+	const pageSize = 100
+
+	shokoHost, shokoApiKey := os.Getenv("SHOKO_HOST"), os.Getenv("SHOKO_API_KEY")
+	if shokoHost == "" || shokoApiKey == "" {
+		return nil, errors.New("$SHOKO_HOST/$SHOKO_API_KEY are required")
+	}
+	shokoSeriesClient, err := shokoApi.NewClient(shokoHost, shokoApiKey, "Series")
+	if err != nil {
+		return nil, err
+	}
+
+	values := url.Values{
+		"pageSize": []string{strconv.Itoa(pageSize)},
+		"page":     []string{""},
+	}
+
+	var fdp []AnimeList.Anime
+	for page := 1; ; page++ {
+		var resp shokoApi.GetSeriesResponse
+		values["page"][0] = strconv.Itoa(page)
+
+		if err = shokoSeriesClient.Get(ctx, "", values, &resp); err != nil {
+			return nil, err
+		}
+
+		for _, s := range resp.List {
+			if s.IDs.AniDB == 0 {
+				continue
+			}
+			p := AnimeList.Anime{Anidbid: s.IDs.AniDB, Name: s.Name}
+			if len(s.IDs.TMDB.Show) > 0 {
+				p.Tmdbtv = s.IDs.TMDB.Show[0]
+			}
+			if len(s.IDs.TvDB) > 0 {
+				p.Tvdbid = strconv.Itoa(s.IDs.TvDB[0])
+			}
+			fdp = append(fdp, p)
+		}
+
+		if len(resp.List) < pageSize || page*pageSize >= resp.Total {
+			return fdp, nil
+		}
+	}
+}
+
+// addShokoSeries allowlists the anime in Shoko Server's collection, which are already curated
+func addShokoSeries(ctx context.Context, allowlistAnidb map[int]struct{}, verbose bool) error {
+	fdp, err := fetchShokoSeries(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range fdp {
+		if verbose {
+			console.Skipped("Allowlisting %s (AniDB %v) from Shoko\n", p.Name, p.Anidbid)
+		}
+		allowlistAnidb[p.Anidbid] = struct{}{}
+	}
+
+	return nil
+}
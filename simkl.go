@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"anime-to-seerr-blocklist/internal/atomicfile"
+	"anime-to-seerr-blocklist/internal/console"
+	"anime-to-seerr-blocklist/internal/simkl"
+)
+
+// Simkl's access tokens don't expire, so unlike Trakt's only the token itself is saved
+const simklTokenFile = "simkl-token"
+
+// runSimklLogin authorises access to the user's Simkl lists using Simkl's PIN flow
+func runSimklLogin(ctx context.Context, opts *options) {
+	clientId := os.Getenv("SIMKL_CLIENT_ID")
+	if clientId == "" {
+		log.Fatal("$SIMKL_CLIENT_ID is required")
+	}
+
+	simklOauthClient, err := simklApi.NewClient(clientId, "", "oauth")
+	if err != nil {
+		log.Fatal(err)
+	}
+	values := url.Values{"client_id": []string{clientId}}
+
+	var pin simklApi.Pin
+	if err = simklOauthClient.Get(ctx, "/pin", values, &pin); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Go to %s and enter the code %s\n", pin.VerificationUrl, pin.UserCode)
+
+	interval := time.Duration(pin.Interval) * time.Second
+	deadline := time.Now().Add(time.Duration(pin.ExpiresIn) * time.Second)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			log.Fatal(context.Cause(ctx))
+		case <-time.After(interval):
+		}
+
+		var status simklApi.PinStatus
+		if err = simklOauthClient.Get(ctx, "/pin/"+url.PathEscape(pin.UserCode), values, &status); err != nil {
+			log.Fatal(err)
+		}
+		if status.Result != simklApi.PinResultOK || status.AccessToken == "" {
+			continue
+		}
+
+		if err = atomicFile.WriteFile(filepath.Join(opts.cacheDir, simklTokenFile), []byte(status.AccessToken+"\n")); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("Logged in to Simkl")
+		return
+	}
+
+	log.Fatal("Simkl code expired")
+}
+
+// addSimklLists allowlists the anime the Simkl user logged in with simkl-login is watching or planning to watch
+func addSimklLists(ctx context.Context, opts *options) {
+	clientId := os.Getenv("SIMKL_CLIENT_ID")
+	if clientId == "" {
+		log.Fatal("$SIMKL_CLIENT_ID is required")
+	}
+
+	b, err := os.ReadFile(filepath.Join(opts.cacheDir, simklTokenFile))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			log.Fatal("Not logged in to Simkl, run simkl-login first")
+		}
+		log.Fatal(err)
+	}
+	simklSyncClient, err := simklApi.NewClient(clientId, strings.TrimSpace(string(b)), "sync")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, status := range []string{simklApi.StatusPlanToWatch, simklApi.StatusWatching} {
+		var resp simklApi.AllItemsResponse
+		// An empty list comes back as an empty body
+		if err = simklSyncClient.Get(ctx, "/all-items/anime/"+status, nil, &resp); err != nil && !errors.Is(err, io.EOF) {
+			log.Fatal(err)
+		}
+
+		for _, item := range resp.Anime {
+			if item.Show.Ids.Anidb == 0 && item.Show.Ids.Tmdb == 0 {
+				continue
+			}
+			if opts.verbose {
+				console.Skipped("Allowlisting %s (AniDB %v) from Simkl\n", item.Show.Title, item.Show.Ids.Anidb)
+			}
+			if item.Show.Ids.Anidb != 0 {
+				opts.allowlistAnidb[int(item.Show.Ids.Anidb)] = struct{}{}
+			}
+			if item.Show.Ids.Tmdb != 0 {
+				opts.allowlist[int(item.Show.Ids.Tmdb)] = struct{}{}
+			}
+		}
+	}
+}
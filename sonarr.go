@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strconv"
+
+	"anime-to-seerr-blocklist/internal/anime-list"
+	"anime-to-seerr-blocklist/internal/arr"
+	"anime-to-seerr-blocklist/internal/console"
+)
+
+func seriesTvdbId(p *AnimeList.Anime) (int, bool) {
+	// Non-numeric IDs are placeholders like "movie" or "unknown"
+	tvdbId, err := strconv.Atoi(p.Tvdbid)
+	return tvdbId, err == nil && tvdbId > 0
+}
+
+// addSonarrExclusions adds every mapped TVDB series to Sonarr's import list exclusions, so that anime can't be added
+// through import lists that bypass Seerr
+func addSonarrExclusions(ctx context.Context, sonarrExclusionClient *arrApi.Client, fdp []AnimeList.Anime, verbose bool) error {
+	var existing []arrApi.ImportListExclusion
+	if err := sonarrExclusionClient.Get(ctx, "", nil, &existing); err != nil {
+		return err
+	}
+
+	excluded := make(map[int]struct{}, len(existing))
+	for _, exclusion := range existing {
+		excluded[exclusion.TvdbId] = struct{}{}
+	}
+
+	for _, p := range fdp {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		tvdbId, ok := seriesTvdbId(&p)
+		if !ok {
+			continue
+		}
+
+		if _, ok := excluded[tvdbId]; ok {
+			continue
+		}
+
+		if verbose {
+			console.Added("Excluding %s (%v) in Sonarr\n", p.Name, tvdbId)
+		}
+		if err := sonarrExclusionClient.Post(ctx, "", nil, &arrApi.ImportListExclusion{TvdbId: tvdbId, Title: p.Name}, nil); err != nil {
+			log.Printf("Error excluding %s (%v) in Sonarr: %v", p.Name, tvdbId, err)
+			continue
+		}
+		excluded[tvdbId] = struct{}{}
+	}
+
+	return nil
+}
+
+// addSonarrSeries allowlists the series monitored in Sonarr, or only those of the anime series type if animeOnly
+func addSonarrSeries(ctx context.Context, sonarrSeriesClient *arrApi.Client, animeOnly bool, allowlist, allowlistTvdb map[int]struct{}, verbose bool) error {
+	var series []arrApi.Series
+	if err := sonarrSeriesClient.Get(ctx, "", nil, &series); err != nil {
+		return err
+	}
+
+	for _, s := range series {
+		if !s.Monitored || (animeOnly && s.SeriesType != arrApi.SeriesTypeAnime) {
+			continue
+		}
+
+		if verbose {
+			console.Skipped("Allowlisting %s (TVDB %v) from Sonarr\n", s.Title, s.TvdbId)
+		}
+		if s.TmdbId != 0 {
+			allowlist[s.TmdbId] = struct{}{}
+		}
+		if s.TvdbId != 0 {
+			allowlistTvdb[s.TvdbId] = struct{}{}
+		}
+	}
+
+	return nil
+}
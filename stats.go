@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"anime-to-seerr-blocklist/internal/anime-list"
+	"anime-to-seerr-blocklist/internal/seerr"
+	"anime-to-seerr-blocklist/pkg/blocklistsync"
+)
+
+// mappingStats counts how the mapping's entries resolve to IDs that can be blocklisted
+type mappingStats struct {
+	total, tmdbTv, tmdbMovie, tvdbOnly, unresolved int
+	adult, unmappedSpecials                        int
+	series                                         map[int]struct{}
+}
+
+func countMapping(fdp []AnimeList.Anime) *mappingStats {
+	stats := &mappingStats{total: len(fdp), series: make(map[int]struct{})}
+
+	for i := range fdp {
+		p := &fdp[i]
+		_, hasTvdb := seriesTvdbId(p)
+		switch {
+		case p.Tmdbtv != 0:
+			stats.tmdbTv++
+			stats.series[p.Tmdbtv] = struct{}{}
+		case len(movieTmdbIds(p)) > 0:
+			stats.tmdbMovie++
+		case hasTvdb:
+			stats.tvdbOnly++
+		default:
+			stats.unresolved++
+		}
+
+		if isAdult(p) {
+			stats.adult++
+		}
+		if isUnmappedSpecial(p) {
+			stats.unmappedSpecials++
+		}
+	}
+
+	return stats
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(total)
+}
+
+func (s *mappingStats) write(w io.Writer) {
+	fmt.Fprintf(w, "Mapping entries:        %6d\n", s.total)
+	fmt.Fprintf(w, "  TMDB TV series:       %6d (%.1f%%)\n", s.tmdbTv, percent(s.tmdbTv, s.total))
+	fmt.Fprintf(w, "  TMDB movie:           %6d (%.1f%%)\n", s.tmdbMovie, percent(s.tmdbMovie, s.total))
+	fmt.Fprintf(w, "  TVDB only:            %6d (%.1f%%)\n", s.tvdbOnly, percent(s.tvdbOnly, s.total))
+	fmt.Fprintf(w, "  Unresolved:           %6d (%.1f%%)\n", s.unresolved, percent(s.unresolved, s.total))
+	fmt.Fprintf(w, "  Adult:                %6d\n", s.adult)
+	fmt.Fprintf(w, "  Unmapped specials:    %6d\n", s.unmappedSpecials)
+	fmt.Fprintf(w, "Unique TMDB TV series:  %6d\n", len(s.series))
+}
+
+func runStats(ctx context.Context, opts *options, args []string) {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	_ = fs.Parse(args)
+
+	// The whole mapping, before any filtering, to show what's lost to it
+	overrides, err := loadMappingOverrides(opts.mappingOverrides, false)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fdp, err := readAnimeList(ctx, opts, overrides, func(*AnimeList.Anime) bool { return true })
+	if err != nil {
+		log.Fatal(err)
+	}
+	fdp = append(fdp, overrides.additions()...)
+	stats := countMapping(fdp)
+	stats.write(os.Stdout)
+
+	seerrHost := os.Getenv("SEERR_HOST")
+	seerrApiKey := os.Getenv("SEERR_API_KEY")
+	if seerrHost == "" || seerrApiKey == "" {
+		return
+	}
+	seerr, err := seerrApi.NewClient(seerrHost, seerrApiKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	blocklisted, partial, err := blocklistSync.Blocklisted(ctx, seerr.Blocklist())
+	if err != nil {
+		log.Fatalf("Error fetching blocklist: %v", err)
+	}
+	n := 0
+	for tmdbId := range stats.series {
+		if _, ok := blocklisted[tmdbId]; ok {
+			n++
+		}
+	}
+	fmt.Printf("  Blocklisted:          %6d (%.1f%%)\n", n, percent(n, len(stats.series)))
+	if partial {
+		fmt.Println("  (the blocklist could only be fetched in part)")
+	}
+}
+
+func writeDrift(w io.Writer, drift *blocklistSync.Drift) {
+	fmt.Fprintf(w, "Removed from the blocklist since being added: %d\n", len(drift.Removed))
+	for _, p := range drift.Removed {
+		fmt.Fprintf(w, "  %s (%v)\n", p.Title, p.TmdbId)
+	}
+	fmt.Fprintf(w, "Missing from the blocklist despite having been synced: %d\n", len(drift.Missing))
+	for _, p := range drift.Missing {
+		fmt.Fprintf(w, "  %s (%v)\n", p.Title, p.TmdbId)
+	}
+	fmt.Fprintf(w, "Kept out by a blocklisted movie with the same TMDB ID: %d\n", len(drift.Conflicts))
+	for _, p := range drift.Conflicts {
+		fmt.Fprintf(w, "  %s (%v)\n", p.Title, p.TmdbId)
+	}
+	fmt.Fprintf(w, "Blocklisted but not from the mapping: %d\n", len(drift.Foreign))
+	for _, tmdbId := range drift.Foreign {
+		fmt.Fprintf(w, "  %v\n", tmdbId)
+	}
+	if drift.Partial {
+		fmt.Fprintln(w, "The blocklist could only be fetched in part, so some series may be wrongly reported as missing")
+	}
+}
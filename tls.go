@@ -0,0 +1,56 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// tlsOptions are the TLS settings for connecting to the servers being configured
+type tlsOptions struct {
+	caCert             string
+	clientCert         string
+	clientKey          string
+	insecureSkipVerify bool
+}
+
+// config returns the tls.Config for o, or nil if o leaves the defaults unchanged
+func (o *tlsOptions) config() (*tls.Config, error) {
+	if o.caCert == "" && o.clientCert == "" && o.clientKey == "" && !o.insecureSkipVerify {
+		return nil, nil
+	}
+	if (o.clientCert == "") != (o.clientKey == "") {
+		return nil, errors.New("-client-cert and -client-key must be given together")
+	}
+
+	config := &tls.Config{
+		InsecureSkipVerify: o.insecureSkipVerify,
+	}
+
+	if o.caCert != "" {
+		pem, err := os.ReadFile(o.caCert)
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", o.caCert)
+		}
+		config.RootCAs = pool
+	}
+
+	if o.clientCert != "" {
+		cert, err := tls.LoadX509KeyPair(o.clientCert, o.clientKey)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+
+	"anime-to-seerr-blocklist/internal/anime-list"
+	"anime-to-seerr-blocklist/internal/console"
+	"anime-to-seerr-blocklist/internal/tmdb"
+)
+
+func newTmdbClient(hardcodedEndpoint string) (*tmdbApi.Client, error) {
+	tmdbApiKey := os.Getenv("TMDB_API_KEY")
+	if tmdbApiKey == "" {
+		return nil, fmt.Errorf("$TMDB_API_KEY is required")
+	}
+	return tmdbApi.NewClient(tmdbApiKey, hardcodedEndpoint)
+}
+
+// discoverTv returns every series TMDB's Discover finds with the given filters
+func discoverTv(ctx context.Context, tmdbDiscoverClient *tmdbApi.Client, values url.Values) ([]tmdbApi.TvResult, error) {
+	var results []tmdbApi.TvResult
+
+	values.Set("page", "")
+	for page := 1; page <= tmdbApi.MaxPage; page++ {
+		var resp tmdbApi.DiscoverTvResponse
+		values["page"][0] = strconv.Itoa(page)
+
+		if err := tmdbDiscoverClient.Get(ctx, "/tv", values, &resp); err != nil {
+			return nil, err
+		}
+		results = append(results, resp.Results...)
+
+		if page >= resp.TotalPages || len(resp.Results) == 0 {
+			break
+		}
+	}
+
+	return results, nil
+}
+
+func tvResultsToAnime(results []tmdbApi.TvResult) []AnimeList.Anime {
+	fdp := make([]AnimeList.Anime, 0, len(results))
+	for _, result := range results {
+		fdp = append(fdp, AnimeList.Anime{Tmdbtv: result.Id, Name: result.Name})
+	}
+	return fdp
+}
+
+// fetchTmdbKeyword returns the series TMDB has tagged with the anime keyword, which picks up new series before
+// they're added to the mapping
+func fetchTmdbKeyword(ctx context.Context) ([]AnimeList.Anime, error) {
+	tmdbDiscoverClient, err := newTmdbClient("discover")
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := discoverTv(ctx, tmdbDiscoverClient, url.Values{
+		"with_keywords": []string{animeKeywordId},
+		"sort_by":       []string{"first_air_date.desc"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tvResultsToAnime(results), nil
+}
+
+// fetchTmdbHeuristic returns the Japanese animated series on TMDB. This catches anime nobody has tagged or mapped yet,
+// at the risk of false positives
+func fetchTmdbHeuristic(ctx context.Context) ([]AnimeList.Anime, error) {
+	tmdbDiscoverClient, err := newTmdbClient("discover")
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := discoverTv(ctx, tmdbDiscoverClient, url.Values{
+		"with_genres":            []string{"16"}, // Animation
+		"with_origin_country":    []string{"JP"},
+		"with_original_language": []string{"ja"},
+		"sort_by":                []string{"first_air_date.desc"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tvResultsToAnime(results), nil
+}
+
+// reportHeuristicOnly lists the series only the heuristic found so they can be checked for false positives
+func reportHeuristicOnly(heuristicFdp, otherFdp []AnimeList.Anime) {
+	known := animeTmdbIdSet(otherFdp)
+	for _, p := range heuristicFdp {
+		if _, ok := known[p.Tmdbtv]; !ok {
+			console.Skipped("Only found by heuristic: %s (%v)\n", p.Name, p.Tmdbtv)
+		}
+	}
+}
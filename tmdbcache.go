@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"anime-to-seerr-blocklist/internal/atomicfile"
+	"anime-to-seerr-blocklist/internal/tmdb"
+)
+
+const tmdbCacheFile = "tmdb-tv.json"
+const tmdbCacheMaxAge = 7 * 24 * time.Hour
+
+type tmdbTvDetails struct {
+	Seasons    []int     `json:"seasons"`
+	Popularity float64   `json:"popularity"`
+	VoteCount  int       `json:"voteCount"`
+	Fetched    time.Time `json:"fetched"`
+}
+
+// tmdbTvCache keeps the details of series looked up on TMDB across runs, as there can be thousands of them
+type tmdbTvCache struct {
+	filename     string
+	entries      map[int]*tmdbTvDetails
+	tmdbTvClient *tmdbApi.Client
+	modified     bool
+}
+
+func openTmdbTvCache(cacheDir string) (*tmdbTvCache, error) {
+	tmdbTvClient, err := newTmdbClient("tv")
+	if err != nil {
+		return nil, err
+	}
+
+	c := &tmdbTvCache{
+		filename:     filepath.Join(cacheDir, tmdbCacheFile),
+		entries:      make(map[int]*tmdbTvDetails),
+		tmdbTvClient: tmdbTvClient,
+	}
+
+	b, err := os.ReadFile(c.filename)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return c, nil
+		}
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &c.entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", c.filename, err)
+	}
+
+	return c, nil
+}
+
+func (c *tmdbTvCache) get(ctx context.Context, tmdbId int) (*tmdbTvDetails, error) {
+	if details, ok := c.entries[tmdbId]; ok && time.Since(details.Fetched) < tmdbCacheMaxAge {
+		return details, nil
+	}
+
+	var resp tmdbApi.TvDetails
+	if err := c.tmdbTvClient.Get(ctx, fmt.Sprintf("/%d", tmdbId), nil, &resp); err != nil {
+		return nil, err
+	}
+
+	details := &tmdbTvDetails{
+		Seasons:    make([]int, 0, len(resp.Seasons)),
+		Popularity: resp.Popularity,
+		VoteCount:  resp.VoteCount,
+		Fetched:    time.Now(),
+	}
+	for _, season := range resp.Seasons {
+		details.Seasons = append(details.Seasons, season.SeasonNumber)
+	}
+
+	c.entries[tmdbId] = details
+	c.modified = true
+	return details, nil
+}
+
+func (c *tmdbTvCache) save() error {
+	if !c.modified {
+		return nil
+	}
+
+	b, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	return atomicFile.WriteFile(c.filename, b)
+}
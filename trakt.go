@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"anime-to-seerr-blocklist/internal/anime-list"
+	"anime-to-seerr-blocklist/internal/atomicfile"
+	"anime-to-seerr-blocklist/internal/console"
+	"anime-to-seerr-blocklist/internal/trakt"
+)
+
+const traktTokenFile = "trakt-token.json"
+
+func saveTraktToken(cacheDir string, token *traktApi.Token) error {
+	b, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	return atomicFile.WriteFile(filepath.Join(cacheDir, traktTokenFile), b)
+}
+
+// traktAccessToken returns the access token saved by trakt-login, refreshing it if it has expired, or "" if there
+// isn't one
+func traktAccessToken(ctx context.Context, cacheDir, clientId, clientSecret string) (string, error) {
+	b, err := os.ReadFile(filepath.Join(cacheDir, traktTokenFile))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	var token traktApi.Token
+	if err = json.Unmarshal(b, &token); err != nil {
+		return "", err
+	}
+
+	if time.Now().Before(time.Unix(token.CreatedAt+token.ExpiresIn, 0).Add(-time.Hour)) {
+		return token.AccessToken, nil
+	}
+
+	traktOauthClient, err := traktApi.NewClient(clientId, "", "oauth")
+	if err != nil {
+		return "", err
+	}
+	err = traktOauthClient.Post(ctx, "/token", nil, &traktApi.RefreshTokenRequest{
+		RefreshToken: token.RefreshToken,
+		ClientId:     clientId,
+		ClientSecret: clientSecret,
+		RedirectUri:  "urn:ietf:wg:oauth:2.0:oob",
+		GrantType:    "refresh_token",
+	}, &token)
+	if err != nil {
+		return "", err
+	}
+
+	return token.AccessToken, saveTraktToken(cacheDir, &token)
+}
+
+// runTraktLogin authorises access to private Trakt lists using the OAuth device flow
+func runTraktLogin(ctx context.Context, opts *options) {
+	clientId, clientSecret := os.Getenv("TRAKT_CLIENT_ID"), os.Getenv("TRAKT_CLIENT_SECRET")
+	if clientId == "" || clientSecret == "" {
+		log.Fatal("$TRAKT_CLIENT_ID/$TRAKT_CLIENT_SECRET are required")
+	}
+
+	traktOauthClient, err := traktApi.NewClient(clientId, "", "oauth")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var code traktApi.DeviceCode
+	if err = traktOauthClient.Post(ctx, "/device/code", nil, map[string]string{"client_id": clientId}, &code); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Go to %s and enter the code %s\n", code.VerificationUrl, code.UserCode)
+
+	interval := time.Duration(code.Interval) * time.Second
+	deadline := time.Now().Add(time.Duration(code.ExpiresIn) * time.Second)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			log.Fatal(context.Cause(ctx))
+		case <-time.After(interval):
+		}
+
+		var token traktApi.Token
+		err = traktOauthClient.Post(ctx, "/device/token", nil, &traktApi.DeviceTokenRequest{
+			Code:         code.DeviceCode,
+			ClientId:     clientId,
+			ClientSecret: clientSecret,
+		}, &token)
+		if err == nil {
+			if err = saveTraktToken(opts.cacheDir, &token); err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println("Logged in to Trakt")
+			return
+		}
+
+		if err, ok := errors.AsType[*traktApi.HTTPError](err); ok {
+			switch err.StatusCode {
+			case http.StatusBadRequest: // pending
+				continue
+			case http.StatusTooManyRequests:
+				interval += time.Second
+				continue
+			}
+		}
+		log.Fatal(err)
+	}
+
+	log.Fatal("Trakt device code expired")
+}
+
+// fetchTraktList returns the shows on a Trakt list given as user/list
+func fetchTraktList(ctx context.Context, traktUserClient *traktApi.Client, list string) ([]traktApi.ListItem, error) {
+	user, slug, ok := strings.Cut(list, "/")
+	if !ok {
+		return nil, fmt.Errorf("Trakt list %q isn't in the form user/list", list)
+	}
+
+	var items []traktApi.ListItem
+	if err := traktUserClient.Get(ctx, fmt.Sprintf("/%s/lists/%s/items/shows", user, slug), nil, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// addTraktLists allowlists the shows on $TRAKT_ALLOWLIST with -allowlist-trakt and blocks those on $TRAKT_BLOCKLIST with
+// -blocklist-trakt in addition to the mapping
+func addTraktLists(ctx context.Context, opts *options) {
+	var allowlist, blocklist string
+	if opts.allowlistTrakt {
+		if allowlist = os.Getenv("TRAKT_ALLOWLIST"); allowlist == "" {
+			log.Fatal("$TRAKT_ALLOWLIST is required")
+		}
+	}
+	if opts.blocklistTrakt {
+		if blocklist = os.Getenv("TRAKT_BLOCKLIST"); blocklist == "" {
+			log.Fatal("$TRAKT_BLOCKLIST is required")
+		}
+	}
+
+	clientId := os.Getenv("TRAKT_CLIENT_ID")
+	if clientId == "" {
+		log.Fatal("$TRAKT_CLIENT_ID is required")
+	}
+	accessToken, err := traktAccessToken(ctx, opts.cacheDir, clientId, os.Getenv("TRAKT_CLIENT_SECRET"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	traktUserClient, err := traktApi.NewClient(clientId, accessToken, "users")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if allowlist != "" {
+		items, err := fetchTraktList(ctx, traktUserClient, allowlist)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, item := range items {
+			if item.Show.Ids.Tmdb != 0 {
+				if opts.verbose {
+					console.Skipped("Allowlisting %s (%v) from Trakt\n", item.Show.Title, item.Show.Ids.Tmdb)
+				}
+				opts.allowlist[item.Show.Ids.Tmdb] = struct{}{}
+			}
+		}
+	}
+
+	if blocklist != "" {
+		items, err := fetchTraktList(ctx, traktUserClient, blocklist)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, item := range items {
+			if item.Show.Ids.Tmdb != 0 {
+				opts.extraBlocklist = append(opts.extraBlocklist, AnimeList.Anime{Tmdbtv: item.Show.Ids.Tmdb, Name: item.Show.Title})
+			}
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"anime-to-seerr-blocklist/internal/anime-list"
+	"anime-to-seerr-blocklist/internal/console"
+	"anime-to-seerr-blocklist/internal/tvdb"
+)
+
+const tvdbCacheFile = "tvdb-series.json"
+const tvdbCacheMaxAge = 7 * 24 * time.Hour
+
+type tvdbSeriesDetails struct {
+	Anime   bool      `json:"anime"`
+	Fetched time.Time `json:"fetched"`
+}
+
+// tvdbSeriesCache keeps whether series looked up on TVDB are in its Anime genre across runs. It only logs in to TVDB
+// once a series isn't cached
+type tvdbSeriesCache struct {
+	filename         string
+	entries          map[int]*tvdbSeriesDetails
+	apiKey, pin      string
+	tvdbSeriesClient *tvdbApi.Client
+	modified         bool
+}
+
+func openTvdbSeriesCache(cacheDir string) (*tvdbSeriesCache, error) {
+	apiKey := os.Getenv("TVDB_API_KEY")
+	if apiKey == "" {
+		return nil, errors.New("$TVDB_API_KEY is required")
+	}
+
+	c := &tvdbSeriesCache{
+		filename: filepath.Join(cacheDir, tvdbCacheFile),
+		entries:  make(map[int]*tvdbSeriesDetails),
+		apiKey:   apiKey,
+		pin:      os.Getenv("TVDB_PIN"),
+	}
+
+	b, err := os.ReadFile(c.filename)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return c, nil
+		}
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &c.entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", c.filename, err)
+	}
+
+	return c, nil
+}
+
+func (c *tvdbSeriesCache) get(ctx context.Context, tvdbId int) (*tvdbSeriesDetails, error) {
+	if details, ok := c.entries[tvdbId]; ok && time.Since(details.Fetched) < tvdbCacheMaxAge {
+		return details, nil
+	}
+
+	if c.tvdbSeriesClient == nil {
+		token, err := tvdbApi.Login(ctx, c.apiKey, c.pin)
+		if err != nil {
+			return nil, fmt.Errorf("cannot log in to TVDB: %w", err)
+		}
+		if c.tvdbSeriesClient, err = tvdbApi.NewClient(token, "series"); err != nil {
+			return nil, err
+		}
+	}
+
+	var resp tvdbApi.SeriesExtendedResponse
+	if err := c.tvdbSeriesClient.Get(ctx, fmt.Sprintf("/%d/extended", tvdbId), url.Values{"short": []string{"true"}}, &resp); err != nil {
+		return nil, err
+	}
+
+	details := &tvdbSeriesDetails{Fetched: time.Now()}
+	for _, genre := range resp.Data.Genres {
+		if genre.Slug == tvdbApi.GenreAnime {
+			details.Anime = true
+			break
+		}
+	}
+
+	c.entries[tvdbId] = details
+	c.modified = true
+	return details, nil
+}
+
+func (c *tvdbSeriesCache) save() error {
+	if !c.modified {
+		return nil
+	}
+
+	b, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.filename, b, 0o644)
+}
+
+// dropNonTvdbAnime drops series from the mapping whose TVDB series isn't in TVDB's Anime genre, as a second opinion on
+// loose mappings. Series without a TVDB series or that can't be looked up are kept
+func dropNonTvdbAnime(ctx context.Context, fdp []AnimeList.Anime, tvdbCache *tvdbSeriesCache, verbose bool) []AnimeList.Anime {
+	notAnime := make(map[int]struct{})
+	checked := make(map[int]struct{})
+	for _, p := range fdp {
+		tvdbId, ok := seriesTvdbId(&p)
+		if !ok || p.Tmdbtv == 0 || ctx.Err() != nil {
+			continue
+		}
+		if _, ok = checked[tvdbId]; ok {
+			continue
+		}
+		checked[tvdbId] = struct{}{}
+
+		details, err := tvdbCache.get(ctx, tvdbId)
+		if err != nil {
+			log.Printf("Error getting genres of TVDB %v: %v", tvdbId, err)
+			if tvdbCache.tvdbSeriesClient == nil {
+				// Not logged in, so every other lookup would fail too
+				break
+			}
+			continue
+		}
+		if !details.Anime {
+			notAnime[tvdbId] = struct{}{}
+		}
+	}
+
+	return slices.DeleteFunc(fdp, func(p AnimeList.Anime) bool {
+		tvdbId, _ := seriesTvdbId(&p)
+		_, ok := notAnime[tvdbId]
+		if ok && verbose {
+			console.Skipped("Skipping %s (%v) as TVDB %v isn't anime\n", p.Name, p.Tmdbtv, tvdbId)
+		}
+		return ok
+	})
+}
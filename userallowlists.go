@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"anime-to-seerr-blocklist/internal/console"
+	"anime-to-seerr-blocklist/internal/seerr"
+)
+
+// addUserAllowlists allowlists the series on each Seerr user's list in -user-allowlists, a JSON object from user IDs or
+// emails to lists of TMDB IDs like -allowlist's. Seerr's blocklist is shared by every user, so a series any of them
+// allowlists stays off it, but a user's list only counts while they still have an account
+func addUserAllowlists(ctx context.Context, opts *options, seerrUserClient *seerrApi.Client) error {
+	b, err := os.ReadFile(opts.userAllowlists)
+	if err != nil {
+		return err
+	}
+	var lists map[string]string
+	if err = json.Unmarshal(b, &lists); err != nil {
+		return fmt.Errorf("parsing %s: %w", opts.userAllowlists, err)
+	}
+	if len(lists) == 0 {
+		return nil
+	}
+
+	users, err := getUsers(ctx, seerrUserClient)
+	if err != nil {
+		return err
+	}
+
+	for key, list := range lists {
+		var user *seerrApi.User
+		for i := range users {
+			if strconv.Itoa(users[i].Id) == key || (users[i].Email != "" && strings.EqualFold(users[i].Email, key)) {
+				user = &users[i]
+				break
+			}
+		}
+		if user == nil {
+			log.Printf("No Seerr user %s, skipping their allowlist", key)
+			continue
+		}
+
+		fdp, err := readIdList(ctx, opts, list)
+		if err != nil {
+			return fmt.Errorf("allowlist of user %s: %w", key, err)
+		}
+		for _, p := range fdp {
+			if opts.verbose {
+				console.Skipped("Allowlisting %s (%v) for user %d\n", p.Name, p.Tmdbtv, user.Id)
+			}
+			opts.allowlist[p.Tmdbtv] = struct{}{}
+		}
+	}
+
+	return nil
+}
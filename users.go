@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"anime-to-seerr-blocklist/internal/console"
+	"anime-to-seerr-blocklist/internal/seerr"
+)
+
+func getUsers(ctx context.Context, seerrUserClient *seerrApi.Client) (users []seerrApi.User, err error) {
+	err = seerrApi.GetPages(ctx, seerrUserClient, nil, func(results []seerrApi.User) {
+		users = append(users, results...)
+	})
+	return
+}
+
+func parseIds(s string) ([]int, error) {
+	var ids []int
+	for field := range strings.SplitSeq(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		id, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// restrictUsers takes away the ability to request series from the given users while leaving movie requests alone.
+// Seerr treats a quota limit of 0 as unlimited, so the request permissions themselves are what get adjusted
+func restrictUsers(ctx context.Context, seerrUserClient *seerrApi.Client, userIds []int, verbose bool) {
+	for _, userId := range userIds {
+		if ctx.Err() != nil {
+			return
+		}
+		endpoint := fmt.Sprintf("/%d/settings/permissions", userId)
+
+		var settings seerrApi.UserPermissionsSettings
+		if err := seerrUserClient.Get(ctx, endpoint, nil, &settings); err != nil {
+			log.Printf("Error getting permissions of user %d: %v", userId, err)
+			continue
+		}
+
+		permissions := settings.Permissions
+		if permissions&seerrApi.PermissionAdmin != 0 {
+			log.Printf("User %d is an admin and cannot be restricted", userId)
+			continue
+		}
+		if permissions&seerrApi.PermissionRequest != 0 {
+			permissions = permissions&^seerrApi.PermissionRequest | seerrApi.PermissionRequestMovie
+		}
+		if permissions&seerrApi.PermissionRequest4k != 0 {
+			permissions = permissions&^seerrApi.PermissionRequest4k | seerrApi.PermissionRequest4kMovie
+		}
+		permissions &^= seerrApi.PermissionRequestTv | seerrApi.PermissionRequest4kTv
+
+		if permissions == settings.Permissions {
+			continue
+		}
+
+		if verbose {
+			console.Removed("Removing series request permissions from user %d\n", userId)
+		}
+		settings.Permissions = permissions
+		if err := seerrUserClient.Post(ctx, endpoint, nil, &settings, nil); err != nil {
+			log.Printf("Error setting permissions of user %d: %v", userId, err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// Set with -ldflags "-X main.version=..." by release builds, otherwise taken from the module version
+var version = ""
+
+const repoURL = "https://github.com/qwerty12/anime-to-seerr-blocklist"
+
+// userAgent identifies the tool to the servers it makes requests to
+func userAgent() string {
+	v := version
+	if info, ok := debug.ReadBuildInfo(); ok && v == "" {
+		v = info.Main.Version
+	}
+	if v == "" || v == "(devel)" {
+		v = "dev"
+	}
+	return "anime-to-seerr-blocklist/" + v + " (+" + repoURL + ")"
+}
+
+// versionString describes the build for -version and bug reports: the version, VCS revision and commit date, and the
+// Go version it was built with
+func versionString() string {
+	v := version
+	var revision, date string
+	var modified bool
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if v == "" {
+			v = info.Main.Version
+		}
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				revision = setting.Value
+			case "vcs.time":
+				date = setting.Value
+			case "vcs.modified":
+				modified = setting.Value == "true"
+			}
+		}
+	}
+	if v == "" {
+		v = "(devel)"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "anime-to-seerr-blocklist %s\n", v)
+	if revision != "" {
+		if modified {
+			revision += " (modified)"
+		}
+		fmt.Fprintf(&b, "revision: %s\n", revision)
+	}
+	if date != "" {
+		fmt.Fprintf(&b, "date: %s\n", date)
+	}
+	fmt.Fprintf(&b, "go: %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	return b.String()
+}
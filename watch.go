@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Editors often write a file in several steps, so a sync waits for the changes to settle
+const watchDebounce = 2 * time.Second
+
+// watchedFiles returns the local files the daemon syncs again on changes to with -watch
+func watchedFiles(opts *options) []string {
+	var files []string
+	if opts.allowlistList != "" && !isIdListSource(opts.allowlistList) {
+		files = append(files, opts.allowlistList)
+	}
+	if opts.mappingFile != "" && opts.mappingFile != "-" && !isUrl(opts.mappingFile) {
+		files = append(files, opts.mappingFile)
+	}
+	if opts.mappingOverrides != "" {
+		files = append(files, opts.mappingOverrides)
+	}
+	if opts.userAllowlists != "" {
+		files = append(files, opts.userAllowlists)
+		// Lists added to it later are only watched once the daemon is restarted
+		if b, err := os.ReadFile(opts.userAllowlists); err == nil {
+			var lists map[string]string
+			if json.Unmarshal(b, &lists) == nil {
+				for _, list := range lists {
+					if !isIdListSource(list) {
+						files = append(files, list)
+					}
+				}
+			}
+		}
+	}
+	return files
+}
+
+// watch requests a sync whenever one of the files changes, until ctx is done. Their directories are watched rather
+// than the files themselves, so that files replaced by renaming another over them are still followed
+func (d *daemon) watch(ctx context.Context, files []string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	watched := make(map[string]struct{}, len(files))
+	for _, file := range files {
+		file, err = filepath.Abs(file)
+		if err != nil {
+			watcher.Close()
+			return err
+		}
+		watched[file] = struct{}{}
+		if err = watcher.Add(filepath.Dir(file)); err != nil {
+			watcher.Close()
+			return err
+		}
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		debounce := time.NewTimer(watchDebounce)
+		debounce.Stop()
+		var changed string
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if _, ok = watched[filepath.Clean(event.Name)]; !ok || event.Op == fsnotify.Chmod {
+					continue
+				}
+				changed = event.Name
+				debounce.Reset(watchDebounce)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("Error watching files: %v", err)
+			case <-debounce.C:
+				log.Printf("%s changed, syncing", changed)
+				d.requestSync()
+			}
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+
+	"anime-to-seerr-blocklist/internal/console"
+	"anime-to-seerr-blocklist/internal/seerr"
+)
+
+// cleanWatchlists removes anime from every user's Seerr watchlist, as blocklisting a title doesn't remove it from
+// watchlists it's already on. Plex watchlist items are read-only through Seerr and are only reported
+func cleanWatchlists(ctx context.Context, seerrUserClient, seerrWatchlistClient *seerrApi.Client, animeTmdbIds map[int]struct{}, verbose bool) error {
+	users, err := getUsers(ctx, seerrUserClient)
+	if err != nil {
+		return err
+	}
+
+	values := url.Values{"page": []string{""}}
+
+	for _, user := range users {
+		var remove []int
+
+		for page := 1; ; page++ {
+			var resp seerrApi.GetUserWatchlistResponse
+			values["page"][0] = strconv.Itoa(page)
+
+			if err = seerrUserClient.Get(ctx, fmt.Sprintf("/%d/watchlist", user.Id), values, &resp); err != nil {
+				log.Printf("Error getting watchlist of user %d: %v", user.Id, err)
+				break
+			}
+
+			for _, item := range resp.Results {
+				if item.MediaType != seerrApi.MediaTypeTv {
+					continue
+				}
+				if _, ok := animeTmdbIds[item.TmdbId]; !ok {
+					continue
+				}
+
+				if item.RatingKey != "" {
+					if verbose {
+						console.Skipped("Not removing %s (%v) from Plex watchlist of user %d\n", item.Title, item.TmdbId, user.Id)
+					}
+					continue
+				}
+
+				if verbose {
+					console.Removed("Removing %s (%v) from watchlist of user %d\n", item.Title, item.TmdbId, user.Id)
+				}
+				remove = append(remove, item.TmdbId)
+			}
+
+			if page >= resp.TotalPages || len(resp.Results) == 0 {
+				break
+			}
+		}
+
+		// Deleted only after paging so that the pages don't shift underneath us
+		userWatchlistClient := seerrWatchlistClient.AsUser(user.Id)
+		for _, tmdbId := range remove {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if err := userWatchlistClient.Delete(ctx, fmt.Sprintf("/%d", tmdbId), nil, nil); err != nil {
+				log.Printf("Error removing %v from watchlist of user %d: %v", tmdbId, user.Id, err)
+			}
+		}
+	}
+
+	return nil
+}